@@ -2,12 +2,24 @@ package main
 
 import (
 	"context"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
 
+	"github.com/ConductorOne/baton-bill/pkg/bill"
+	"github.com/ConductorOne/baton-bill/pkg/connector"
 	"github.com/conductorone/baton-sdk/pkg/cli"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
+// sessionCacheKeyEnv is the environment variable holding the session cache encryption key.
+const sessionCacheKeyEnv = "BATON_SESSION_CACHE_KEY"
+
 // config defines the external configuration required for the connector to run.
 type config struct {
 	cli.BaseConfig `mapstructure:",squash"` // Puts the base config options in the same place as the connector options
@@ -16,10 +28,42 @@ type config struct {
 	Password        string   `mapstructure:"password"`
 	OrganizationIds []string `mapstructure:"organizationIds"`
 	DeveloperKey    string   `mapstructure:"developerKey"`
+	Environment     string   `mapstructure:"environment"`
+	BaseURL         string   `mapstructure:"base-url"`
+	MFAId           string   `mapstructure:"mfa-id"`
+	MFADeviceId     string   `mapstructure:"mfa-device-id"`
+
+	SessionCacheFile    string `mapstructure:"session-cache-file"`
+	SessionCacheKeyFile string `mapstructure:"session-cache-key-file"`
+
+	MaxConcurrentRequests int           `mapstructure:"max-concurrent-requests"`
+	RequestsPerPeriod     int           `mapstructure:"requests-per-period"`
+	RateLimitPeriod       time.Duration `mapstructure:"rate-limit-period"`
+	MaxRetries            int           `mapstructure:"max-retries"`
+
+	RedactFields []string `mapstructure:"redact-fields"`
+
+	RevokeFallbackRole string   `mapstructure:"revoke-fallback-role"`
+	DefaultRole        string   `mapstructure:"default-role"`
+	ProtectedRoles     []string `mapstructure:"protected-roles"`
+	DryRun             bool     `mapstructure:"dry-run"`
+
+	RecordDir string `mapstructure:"record-dir"`
+	ReplayDir string `mapstructure:"replay-dir"`
 }
 
 // validateConfig is run after the configuration is loaded, and should return an error if it isn't valid.
 func validateConfig(ctx context.Context, cfg *config) error {
+	if cfg.OrganizationIds == nil || len(cfg.OrganizationIds) == 0 {
+		return fmt.Errorf("organizationIds are missing")
+	}
+
+	return validateClientConfig(ctx, cfg)
+}
+
+// validateClientConfig validates the configuration of the Bill.com API client. Commands working across all the
+// organizations of the user run it instead of validateConfig, as they don't need organizationIds.
+func validateClientConfig(ctx context.Context, cfg *config) error {
 	if cfg.Username == "" {
 		return fmt.Errorf("username is missing")
 	}
@@ -28,14 +72,51 @@ func validateConfig(ctx context.Context, cfg *config) error {
 		return fmt.Errorf("password is missing")
 	}
 
-	if cfg.OrganizationIds == nil || len(cfg.OrganizationIds) == 0 {
-		return fmt.Errorf("organizationIds are missing")
-	}
-
 	if cfg.DeveloperKey == "" {
 		return fmt.Errorf("developerKey is missing")
 	}
 
+	if _, err := bill.BaseURLForEnvironment(cfg.Environment); err != nil {
+		return fmt.Errorf("environment must be either %s or %s", bill.EnvironmentProduction, bill.EnvironmentSandbox)
+	}
+
+	if cfg.BaseURL != "" {
+		u, err := url.Parse(cfg.BaseURL)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("base-url must be an absolute URL")
+		}
+	}
+
+	if cfg.MFAId != "" && cfg.MFADeviceId == "" {
+		return fmt.Errorf("mfa-device-id is required when mfa-id is set")
+	}
+
+	if cfg.SessionCacheFile != "" && cfg.SessionCacheKeyFile == "" && os.Getenv(sessionCacheKeyEnv) == "" {
+		return fmt.Errorf("session-cache-key-file or $%s is required when session-cache-file is set", sessionCacheKeyEnv)
+	}
+
+	if cfg.SessionCacheFile != "" {
+		if _, err := sessionCacheKey(cfg); err != nil {
+			return err
+		}
+	}
+
+	if cfg.MaxConcurrentRequests < 1 {
+		return fmt.Errorf("max-concurrent-requests must be at least 1")
+	}
+
+	if cfg.RequestsPerPeriod < 1 {
+		return fmt.Errorf("requests-per-period must be at least 1")
+	}
+
+	if cfg.MaxRetries < 0 {
+		return fmt.Errorf("max-retries must not be negative")
+	}
+
+	if cfg.RecordDir != "" && cfg.ReplayDir != "" {
+		return fmt.Errorf("record-dir and replay-dir can't be used together")
+	}
+
 	return nil
 }
 
@@ -45,4 +126,92 @@ func cmdFlags(cmd *cobra.Command) {
 	cmd.PersistentFlags().String("password", "", "The Bill password used to connect to the Bill API. ($BATON_BILL_PASSWORD)")
 	cmd.PersistentFlags().StringSlice("organizationIds", []string{}, "The Bill organizationIds used to connect to the Bill API. ($BATON_BILL_ORGANIZATION_IDS)")
 	cmd.PersistentFlags().String("developerKey", "", "The Bill developerKey used to connect to the Bill API. ($BATON_BILL_DEVELOPER_KEY)")
+	cmd.PersistentFlags().String("environment", bill.EnvironmentProduction, "The Bill environment to connect to: production, sandbox. ($BATON_ENVIRONMENT)")
+	cmd.PersistentFlags().String("base-url", "", "The Bill API base URL, overrides the URL of the environment. ($BATON_BASE_URL)")
+	cmd.PersistentFlags().String("mfa-id", "", "The MFA id of a remembered device, printed by the mfa-setup command. ($BATON_MFA_ID)")
+	cmd.PersistentFlags().String("mfa-device-id", "", "The id of the device remembered by the mfa-setup command. ($BATON_MFA_DEVICE_ID)")
+	cmd.PersistentFlags().String("session-cache-file", "", "The path of the encrypted file used to reuse Bill sessions between runs. ($BATON_SESSION_CACHE_FILE)")
+	cmd.PersistentFlags().String(
+		"session-cache-key-file",
+		"",
+		"The path of the file holding the base64 encoded 32-byte session cache encryption key, $"+sessionCacheKeyEnv+" can be used instead. ($BATON_SESSION_CACHE_KEY_FILE)",
+	)
+	cmd.PersistentFlags().Int("max-concurrent-requests", bill.DefaultMaxConcurrentRequests, "The maximum number of concurrent requests sent to the Bill API. ($BATON_MAX_CONCURRENT_REQUESTS)")
+	cmd.PersistentFlags().Int("requests-per-period", bill.DefaultRequestsPerPeriod, "The maximum number of requests sent to the Bill API per rate limit period. ($BATON_REQUESTS_PER_PERIOD)")
+	cmd.PersistentFlags().Duration("rate-limit-period", bill.DefaultRateLimitPeriod, "The period of the Bill API request rate limit. ($BATON_RATE_LIMIT_PERIOD)")
+	cmd.PersistentFlags().Int("max-retries", bill.DefaultMaxRetries, "The number of times a rate limited request to the Bill API is retried. ($BATON_MAX_RETRIES)")
+	cmd.PersistentFlags().String("revoke-fallback-role", "", "The id or name of the role users are moved to when their role is revoked. ($BATON_REVOKE_FALLBACK_ROLE)")
+	cmd.PersistentFlags().String("default-role", "", "The id or name of the role of users created when organization membership is granted. ($BATON_DEFAULT_ROLE)")
+	cmd.PersistentFlags().StringSlice(
+		"protected-roles",
+		connector.DefaultProtectedRoles,
+		"The ids, names or types of the roles provisioning never leaves without an active user. ($BATON_PROTECTED_ROLES)",
+	)
+	cmd.PersistentFlags().Bool("dry-run", false, "Log the Bill API writes of provisioning, with secrets redacted, instead of sending them. ($BATON_DRY_RUN)")
+	cmd.PersistentFlags().StringSlice(
+		"redact-fields",
+		bill.DefaultRedactFields,
+		"The names of PII fields masked in logs and recordings, in addition to credentials and session ids. ($BATON_REDACT_FIELDS)",
+	)
+	cmd.PersistentFlags().String("record-dir", "", "The directory where the Bill API requests and responses are recorded, with credentials redacted. ($BATON_RECORD_DIR)")
+	cmd.PersistentFlags().String("replay-dir", "", "The directory of recorded Bill API responses to replay instead of calling the Bill API. ($BATON_REPLAY_DIR)")
+}
+
+// loadConfig populates the config from the config file, the environment and the flags of a subcommand,
+// the same way the root command does.
+func loadConfig(cmd *cobra.Command, cfg *config) (*viper.Viper, error) {
+	v := viper.New()
+	v.SetConfigType("yaml")
+
+	if path := os.Getenv("BATON_CONFIG_PATH"); path != "" {
+		v.SetConfigFile(path)
+	} else {
+		v.SetConfigName(".baton")
+		v.AddConfigPath(".")
+	}
+
+	if err := v.ReadInConfig(); err != nil {
+		var notFoundErr viper.ConfigFileNotFoundError
+		if !errors.As(err, &notFoundErr) {
+			return nil, err
+		}
+	}
+
+	v.SetEnvPrefix("baton")
+	v.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
+	v.AutomaticEnv()
+
+	if err := v.BindPFlags(cmd.Flags()); err != nil {
+		return nil, err
+	}
+
+	if err := v.Unmarshal(cfg); err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// sessionCacheKey returns the session cache encryption key, base64 encoded in the key file or the environment.
+func sessionCacheKey(cfg *config) ([]byte, error) {
+	encoded := os.Getenv(sessionCacheKeyEnv)
+	if cfg.SessionCacheKeyFile != "" {
+		data, err := os.ReadFile(cfg.SessionCacheKeyFile)
+		if err != nil {
+			return nil, err
+		}
+
+		encoded = string(data)
+	}
+
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("the session cache key must be base64 encoded: %w", err)
+	}
+
+	if len(key) != bill.SessionCacheKeySize {
+		return nil, fmt.Errorf("the session cache key must be %d bytes, like the output of `openssl rand -base64 %d`", bill.SessionCacheKeySize, bill.SessionCacheKeySize)
+	}
+
+	return key, nil
 }
@@ -3,25 +3,34 @@ package main
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
+	"sync"
+	"time"
 
 	"github.com/ConductorOne/baton-bill/pkg/bill"
 	"github.com/ConductorOne/baton-bill/pkg/connector"
 	"github.com/conductorone/baton-sdk/pkg/cli"
 	"github.com/conductorone/baton-sdk/pkg/connectorbuilder"
-	"github.com/conductorone/baton-sdk/pkg/sdk"
 	"github.com/conductorone/baton-sdk/pkg/types"
 	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"github.com/spf13/cobra"
 	"go.uber.org/zap"
 )
 
 var version = "dev"
 
+// connectorServiceCmd is the hidden command of the SDK running the connector in a subprocess.
+const connectorServiceCmd = "_connector-service"
+
+// closeTimeout bounds the time spent logging out of Bill sessions on shutdown.
+const closeTimeout = 30 * time.Second
+
 func main() {
 	ctx := context.Background()
 
 	cfg := &config{}
-	cmd, err := cli.NewCmd(ctx, "baton-bill", cfg, validateConfig, getConnector, run)
+	cmd, err := cli.NewCmd(ctx, "baton-bill", cfg, validateConfig, getConnector)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err.Error())
 		os.Exit(1)
@@ -29,6 +38,9 @@ func main() {
 
 	cmd.Version = version
 	cmdFlags(cmd)
+	closeConnectorService(cmd)
+	cmd.AddCommand(mfaSetupCmd(ctx, cfg))
+	cmd.AddCommand(offboardCmd(ctx, cfg))
 
 	err = cmd.Execute()
 	if err != nil {
@@ -37,18 +49,82 @@ func main() {
 	}
 }
 
-func getConnector(ctx context.Context, cfg *config) (types.ConnectorServer, error) {
-	l := ctxzap.Extract(ctx)
+// clientOptions returns the options of the Bill.com API client built from the configuration.
+func clientOptions(cfg *config) ([]bill.ClientOption, error) {
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		var err error
+
+		baseURL, err = bill.BaseURLForEnvironment(cfg.Environment)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	opts := []bill.ClientOption{
+		bill.WithBaseURL(baseURL),
+		bill.WithRateLimit(bill.RateLimitConfig{
+			MaxConcurrentRequests: cfg.MaxConcurrentRequests,
+			RequestsPerPeriod:     cfg.RequestsPerPeriod,
+			Period:                cfg.RateLimitPeriod,
+			MaxRetries:            cfg.MaxRetries,
+		}),
+		bill.WithDryRun(cfg.DryRun),
+	}
+
+	if cfg.SessionCacheFile != "" {
+		key, err := sessionCacheKey(cfg)
+		if err != nil {
+			return nil, err
+		}
+
+		cache, err := bill.NewFileSessionCache(cfg.SessionCacheFile, key)
+		if err != nil {
+			return nil, err
+		}
 
-	billConnector, err := connector.New(
+		opts = append(opts, bill.WithSessionCache(cache))
+	}
+
+	return opts, nil
+}
+
+// credentials returns the Bill.com API credentials from the configuration.
+func credentials(cfg *config) bill.Credentials {
+	return bill.Credentials{
+		Username:     cfg.Username,
+		Password:     cfg.Password,
+		DeveloperKey: cfg.DeveloperKey,
+		MFAId:        cfg.MFAId,
+		DeviceId:     cfg.MFADeviceId,
+	}
+}
+
+// newConnector creates the Bill connector from the configuration.
+func newConnector(ctx context.Context, cfg *config) (*connector.Bill, error) {
+	opts, err := clientOptions(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return connector.New(
 		ctx,
 		cfg.OrganizationIds,
-		bill.Credentials{
-			Username:     cfg.Username,
-			Password:     cfg.Password,
-			DeveloperKey: cfg.DeveloperKey,
-		},
+		credentials(cfg),
+		connector.WithClientOptions(opts...),
+		connector.WithRedactFields(cfg.RedactFields...),
+		connector.WithRevokeFallbackRole(cfg.RevokeFallbackRole),
+		connector.WithDefaultRole(cfg.DefaultRole),
+		connector.WithProtectedRoles(cfg.ProtectedRoles...),
+		connector.WithRecordDir(cfg.RecordDir),
+		connector.WithReplayDir(cfg.ReplayDir),
 	)
+}
+
+func getConnector(ctx context.Context, cfg *config) (types.ConnectorServer, error) {
+	l := ctxzap.Extract(ctx)
+
+	billConnector, err := newConnector(ctx, cfg)
 	if err != nil {
 		l.Error("error creating connector", zap.Error(err))
 		return nil, err
@@ -57,34 +133,79 @@ func getConnector(ctx context.Context, cfg *config) (types.ConnectorServer, erro
 	connector, err := connectorbuilder.NewConnector(ctx, billConnector)
 	if err != nil {
 		l.Error("error creating connector", zap.Error(err))
+		closeConnector(ctx, billConnector)
 		return nil, err
 	}
 
+	running.mtx.Lock()
+	running.ctx = ctx
+	running.connector = billConnector
+	running.mtx.Unlock()
+
 	return connector, nil
 }
 
-// run is where the process of syncing with the connector is implemented.
-func run(ctx context.Context, cfg *config) error {
-	l := ctxzap.Extract(ctx)
+// running is the Bill connector created by getConnector, with the context it was created with.
+var running struct {
+	mtx       sync.Mutex
+	ctx       context.Context
+	connector *connector.Bill
+}
 
-	c, err := getConnector(ctx, cfg)
-	if err != nil {
-		l.Error("error creating connector", zap.Error(err))
-		return err
+// closeRunning logs out of the sessions of the connector created by getConnector, if there is one.
+func closeRunning() {
+	running.mtx.Lock()
+	defer running.mtx.Unlock()
+
+	if running.connector != nil {
+		closeConnector(running.ctx, running.connector)
+		running.connector = nil
 	}
+}
 
-	r, err := sdk.NewConnectorRunner(ctx, c, cfg.C1zPath)
-	if err != nil {
-		l.Error("error creating connector runner", zap.Error(err))
-		return err
+// closeConnectorService makes the connector service log out of the Bill sessions before it exits. The SDK syncs
+// and provisions through that subprocess, which exits as soon as its parent closes its stdin, so stdin is relayed
+// through a pipe that is only closed once the connector is.
+func closeConnectorService(cmd *cobra.Command) {
+	for _, c := range cmd.Commands() {
+		if c.Name() != connectorServiceCmd {
+			continue
+		}
+
+		runE := c.RunE
+		c.RunE = func(cmd *cobra.Command, args []string) error {
+			defer closeRunning()
+
+			r, w, err := os.Pipe()
+			if err != nil {
+				return err
+			}
+
+			stdin := os.Stdin
+			os.Stdin = r
+
+			go func() {
+				// the parent writes the server config and then keeps stdin open until it's done with the service
+				_, _ = io.Copy(w, stdin)
+				closeRunning()
+				_ = w.Close()
+			}()
+
+			return runE(cmd, args)
+		}
 	}
-	defer r.Close()
+}
 
-	err = r.Run(ctx)
+// closeConnector logs out of the Bill sessions. It doesn't use the run context, which is canceled when the
+// sync is interrupted, so the sessions are closed even then.
+func closeConnector(ctx context.Context, billConnector *connector.Bill) {
+	l := ctxzap.Extract(ctx)
+
+	closeCtx, cancel := context.WithTimeout(ctxzap.ToContext(context.Background(), l), closeTimeout)
+	defer cancel()
+
+	err := billConnector.Close(closeCtx)
 	if err != nil {
-		l.Error("error running connector", zap.Error(err))
-		return err
+		l.Error("error closing connector", zap.Error(err))
 	}
-
-	return nil
 }
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ConductorOne/baton-bill/pkg/bill"
+	"github.com/conductorone/baton-sdk/pkg/logging"
+	"github.com/conductorone/baton-sdk/pkg/uhttp"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+const mfaMachineName = "baton-bill"
+
+// mfaSetupCmd returns the command that authenticates the integration user with multi-factor authentication once
+// and prints the MFA id and device id to reuse in later syncs.
+func mfaSetupCmd(ctx context.Context, cfg *config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "mfa-setup",
+		Short: "Remember this device for Bill multi-factor authentication",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			v, err := loadConfig(cmd, cfg)
+			if err != nil {
+				return err
+			}
+
+			loggerCtx, err := logging.Init(ctx, logging.WithLogFormat(v.GetString("log-format")), logging.WithLogLevel(v.GetString("log-level")))
+			if err != nil {
+				return err
+			}
+
+			err = validateConfig(loggerCtx, cfg)
+			if err != nil {
+				return err
+			}
+
+			return runMFASetup(loggerCtx, cfg, v.GetBool("use-backup"))
+		},
+	}
+
+	cmd.Flags().Bool("use-backup", false, "Send the MFA code to the backup phone number.")
+
+	return cmd
+}
+
+func runMFASetup(ctx context.Context, cfg *config, useBackup bool) error {
+	l := ctxzap.Extract(ctx)
+
+	httpClient, err := uhttp.NewClient(ctx, uhttp.WithLogger(true, l))
+	if err != nil {
+		return err
+	}
+
+	opts, err := clientOptions(cfg)
+	if err != nil {
+		return err
+	}
+
+	// the device is remembered for the login used by the setup, so it must not be a trusted login already
+	creds := credentials(cfg)
+	creds.MFAId = ""
+
+	deviceId := cfg.MFADeviceId
+	if deviceId == "" {
+		deviceId, err = newDeviceId()
+		if err != nil {
+			return err
+		}
+	}
+
+	client := bill.NewClient(httpClient, creds, opts...)
+	organizationId := cfg.OrganizationIds[0]
+
+	defer func() {
+		err := client.Close(ctx)
+		if err != nil {
+			l.Error("error closing bill client", zap.Error(err))
+		}
+	}()
+
+	challengeId, err := client.MFAChallenge(ctx, organizationId, useBackup)
+	if err != nil {
+		l.Error("error requesting MFA challenge", zap.Error(err))
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Enter the MFA code sent by Bill: ")
+
+	token, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return err
+	}
+
+	mfaId, err := client.MFAAuthenticate(ctx, organizationId, challengeId, strings.TrimSpace(token), deviceId, mfaMachineName)
+	if err != nil {
+		l.Error("error authenticating MFA challenge", zap.Error(err))
+		return err
+	}
+
+	fmt.Println("Device remembered. Use these settings in later syncs:")
+	fmt.Printf("  --mfa-id %s ($BATON_MFA_ID)\n", mfaId)
+	fmt.Printf("  --mfa-device-id %s ($BATON_MFA_DEVICE_ID)\n", deviceId)
+
+	return nil
+}
+
+func newDeviceId() (string, error) {
+	b := make([]byte, 16)
+
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
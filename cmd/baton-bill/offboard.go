@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/ConductorOne/baton-bill/pkg/connector"
+	"github.com/conductorone/baton-sdk/pkg/logging"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+// offboardCmd returns the command that deactivates a person in every Bill.com organization the credentials can
+// access, and prints the result for each organization.
+func offboardCmd(ctx context.Context, cfg *config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "offboard",
+		Short: "Deactivate a user in every Bill organization",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			v, err := loadConfig(cmd, cfg)
+			if err != nil {
+				return err
+			}
+
+			loggerCtx, err := logging.Init(ctx, logging.WithLogFormat(v.GetString("log-format")), logging.WithLogLevel(v.GetString("log-level")))
+			if err != nil {
+				return err
+			}
+
+			err = validateClientConfig(loggerCtx, cfg)
+			if err != nil {
+				return err
+			}
+
+			email := v.GetString("email")
+			if email == "" {
+				return fmt.Errorf("email is missing")
+			}
+
+			// failures in organizations are in the results table, the usage would only hide them
+			cmd.SilenceUsage = true
+
+			return runOffboard(loggerCtx, cfg, email)
+		},
+	}
+
+	cmd.Flags().String("email", "", "The email of the user to deactivate.")
+
+	return cmd
+}
+
+func runOffboard(ctx context.Context, cfg *config, email string) error {
+	l := ctxzap.Extract(ctx)
+
+	billConnector, err := newConnector(ctx, cfg)
+	if err != nil {
+		l.Error("error creating connector", zap.Error(err))
+		return err
+	}
+	defer closeConnector(ctx, billConnector)
+
+	results, err := billConnector.Offboard(ctx, email)
+	if err != nil {
+		l.Error("error offboarding user", zap.Error(err))
+		return err
+	}
+
+	err = printOffboardResults(os.Stdout, results)
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, result := range results {
+		if result.Result == connector.OffboardFailed {
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("offboarding %s failed in %d of %d organizations", email, failed, len(results))
+	}
+
+	return nil
+}
+
+func printOffboardResults(out io.Writer, results []connector.OffboardResult) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(w, "ORGANIZATION\tORGANIZATION ID\tUSER ID\tRESULT\tERROR")
+	for _, result := range results {
+		errMsg := ""
+		if result.Err != nil {
+			errMsg = result.Err.Error()
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", result.OrganizationName, result.OrganizationId, result.UserId, result.Result, errMsg)
+	}
+
+	return w.Flush()
+}
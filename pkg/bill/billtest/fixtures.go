@@ -0,0 +1,65 @@
+package billtest
+
+import (
+	"github.com/ConductorOne/baton-bill/pkg/bill"
+)
+
+// DefaultFixtures returns a small data set with two organizations that share a standard profile name.
+func DefaultFixtures() Fixtures {
+	return Fixtures{
+		Username:     "integration@example.com",
+		Password:     "password",
+		DeveloperKey: "dev-key",
+		MFAToken:     "123456",
+		Organizations: []bill.Organization{
+			{Id: "org-1", Name: "Acme"},
+			{Id: "org-2", Name: "Acme Europe"},
+		},
+		Users: map[string][]bill.User{
+			"org-1": {
+				{
+					BaseResource: bill.BaseResource{Id: "usr-1"},
+					FirstName:    "Ada",
+					LastName:     "Admin",
+					Email:        "ada@example.com",
+					IsActive:     true,
+					RoleId:       "pro-1",
+					TimezoneId:   "7",
+					CreatedTime:  "2023-01-10T09:30:00.000+0000",
+					UpdatedTime:  "2023-06-02T14:05:00.000+0000",
+				},
+				{BaseResource: bill.BaseResource{Id: "usr-2"}, FirstName: "Carl", LastName: "Clerk", Email: "carl@example.com", IsActive: true, RoleId: "pro-2"},
+				{BaseResource: bill.BaseResource{Id: "usr-3"}, FirstName: "Dora", LastName: "Former", Email: "dora@example.com", IsActive: false, RoleId: "pro-2"},
+			},
+			"org-2": {
+				{BaseResource: bill.BaseResource{Id: "usr-4"}, FirstName: "Ada", LastName: "Admin", Email: "ada@example.com", IsActive: true, RoleId: "pro-3"},
+			},
+		},
+		Profiles: map[string][]bill.UserRoleProfile{
+			"org-1": {
+				{BaseResource: bill.BaseResource{Id: "pro-1"}, Name: "Administrator", Type: "1", Description: "Full access"},
+				{BaseResource: bill.BaseResource{Id: "pro-2"}, Name: "Clerk", Type: "2", Description: "Enters bills"},
+			},
+			"org-2": {
+				{BaseResource: bill.BaseResource{Id: "pro-3"}, Name: "Administrator", Type: "1", Description: "Full access"},
+			},
+		},
+		Permissions: map[string]map[string]bool{
+			"pro-1": {"approveBills": true, "payBills": true, "manageUsers": true},
+			"pro-2": {"approveBills": false, "payBills": false, "createBills": true},
+			"pro-3": {"approveBills": true, "payBills": true, "manageUsers": true},
+		},
+		ApprovalPolicies: map[string][]bill.ApprovalPolicy{
+			"org-1": {
+				{BaseResource: bill.BaseResource{Id: "apo-1"}, Name: "Bills over 1000", IsActive: true, ObjectType: "Bill", MinAmount: "1000"},
+			},
+		},
+		ApprovalPolicyApprovers: map[string][]bill.ApprovalPolicyApprover{
+			"org-1": {
+				{BaseResource: bill.BaseResource{Id: "apa-1"}, IsActive: true, ApprovalPolicyId: "apo-1", UserId: "usr-2", SortOrder: "0"},
+				{BaseResource: bill.BaseResource{Id: "apa-2"}, IsActive: true, ApprovalPolicyId: "apo-1", UserId: "usr-1", SortOrder: "1"},
+				{BaseResource: bill.BaseResource{Id: "apa-3"}, IsActive: false, ApprovalPolicyId: "apo-1", UserId: "usr-3", SortOrder: "2"},
+			},
+		},
+	}
+}
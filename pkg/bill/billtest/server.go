@@ -0,0 +1,662 @@
+// Package billtest provides an in-process fake of the Bill.com v2 API for tests.
+package billtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/ConductorOne/baton-bill/pkg/bill"
+)
+
+// Fixtures seed the data served by the fake API.
+type Fixtures struct {
+	Username      string              `json:"username"`
+	Password      string              `json:"password"`
+	DeveloperKey  string              `json:"developerKey"`
+	Organizations []bill.Organization `json:"organizations"`
+	// Users and Profiles are keyed by organization id.
+	Users    map[string][]bill.User            `json:"users"`
+	Profiles map[string][]bill.UserRoleProfile `json:"profiles"`
+	// Permissions are keyed by profile id.
+	Permissions map[string]map[string]bool `json:"permissions"`
+	// ApprovalPolicies and ApprovalPolicyApprovers are keyed by organization id.
+	ApprovalPolicies        map[string][]bill.ApprovalPolicy         `json:"approvalPolicies"`
+	ApprovalPolicyApprovers map[string][]bill.ApprovalPolicyApprover `json:"approvalPolicyApprovers"`
+	// MFAToken is the code accepted by MFAAuthenticate.
+	MFAToken string `json:"mfaToken"`
+}
+
+// LoadFixtures reads fixtures from a JSON file.
+func LoadFixtures(path string) (Fixtures, error) {
+	var fixtures Fixtures
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fixtures, err
+	}
+
+	err = json.Unmarshal(data, &fixtures)
+
+	return fixtures, err
+}
+
+// InjectedError is returned by the fake API instead of the regular response.
+type InjectedError struct {
+	// StatusCode is the HTTP status of the response, 200 if not set, as Bill.com reports most errors in the body.
+	StatusCode int
+	Code       string
+	Message    string
+	// Times is how many requests fail with the error, 1 if not set.
+	Times int
+}
+
+// Server is a fake Bill.com API served over HTTP.
+type Server struct {
+	*httptest.Server
+
+	mtx          sync.Mutex
+	fixtures     Fixtures
+	sessions     map[string]string
+	sessionCount int
+	userCount    int
+	errors       map[string][]*InjectedError
+	requests     map[string]int
+
+	// trusted holds the MFA-trusted session ids, devices the device ids remembered for each MFA id.
+	trusted map[string]bool
+	devices map[string]string
+}
+
+// NewServer starts a fake API serving the fixtures. Call Close when done.
+func NewServer(fixtures Fixtures) *Server {
+	s := &Server{
+		fixtures: fixtures,
+		sessions: make(map[string]string),
+		trusted:  make(map[string]bool),
+		devices:  make(map[string]string),
+		errors:   make(map[string][]*InjectedError),
+		requests: make(map[string]int),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/Login.json", s.handle(s.login))
+	mux.HandleFunc("/api/v2/Logout.json", s.handle(s.logout))
+	mux.HandleFunc("/api/v2/ListOrgs.json", s.handle(s.listOrganizations))
+	mux.HandleFunc("/api/v2/GetSessionInfo.json", s.handle(s.sessionInfo))
+	mux.HandleFunc("/api/v2/MFAChallenge.json", s.handle(s.mfaChallenge))
+	mux.HandleFunc("/api/v2/MFAAuthenticate.json", s.handle(s.mfaAuthenticate))
+	mux.HandleFunc("/api/v2/MFAStatus.json", s.handle(s.mfaStatus))
+	mux.HandleFunc("/api/v2/List/User.json", s.handle(s.listUsers))
+	mux.HandleFunc("/api/v2/Crud/Read/User.json", s.handle(s.readUser))
+	mux.HandleFunc("/api/v2/Crud/Update/User.json", s.handle(s.updateUser))
+	mux.HandleFunc("/api/v2/Crud/Create/User.json", s.handle(s.createUser))
+	mux.HandleFunc("/api/v2/List/Profile.json", s.handle(s.listProfiles))
+	mux.HandleFunc("/api/v2/Crud/Read/Profile.json", s.handle(s.readProfile))
+	mux.HandleFunc("/api/v2/GetProfilePermissions.json", s.handle(s.profilePermissions))
+	mux.HandleFunc("/api/v2/List/ApprovalPolicy.json", s.handle(s.listApprovalPolicies))
+	mux.HandleFunc("/api/v2/List/ApprovalPolicyApprover.json", s.handle(s.listApprovalPolicyApprovers))
+
+	s.Server = httptest.NewServer(mux)
+
+	return s
+}
+
+// BaseURL returns the API base URL to pass to bill.WithBaseURL.
+func (s *Server) BaseURL() string {
+	return s.URL + "/api/v2"
+}
+
+// InjectError makes the next requests to the endpoint path, e.g. `/List/User.json`, fail with the error.
+func (s *Server) InjectError(path string, injected InjectedError) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if injected.Times <= 0 {
+		injected.Times = 1
+	}
+
+	s.errors[path] = append(s.errors[path], &injected)
+}
+
+// ExpireSessions invalidates every session, like Bill.com does after a period of inactivity.
+func (s *Server) ExpireSessions() {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.sessions = make(map[string]string)
+}
+
+// Sessions returns the number of open sessions.
+func (s *Server) Sessions() int {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return len(s.sessions)
+}
+
+// Requests returns how many requests were sent to the endpoint path.
+func (s *Server) Requests(path string) int {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.requests[path]
+}
+
+// request is a parsed Bill.com API request.
+type request struct {
+	form           map[string]string
+	data           map[string]json.RawMessage
+	organizationId string
+}
+
+func (r *request) dataString(key string) string {
+	var value string
+	_ = json.Unmarshal(r.data[key], &value)
+
+	return value
+}
+
+func (r *request) dataInt(key string) int {
+	var value int
+	_ = json.Unmarshal(r.data[key], &value)
+
+	return value
+}
+
+// apiError is an error reported in the Bill.com response envelope.
+type apiError struct {
+	statusCode int
+	code       string
+	message    string
+}
+
+func errInvalidSession() *apiError {
+	return &apiError{code: bill.ErrCodeInvalidSession, message: "Session is invalid. Please log in."}
+}
+
+func errNotFound(id string) *apiError {
+	return &apiError{code: bill.ErrCodeObjectNotFound, message: fmt.Sprintf("Object %s not found.", id)}
+}
+
+func errInvalidCredentials() *apiError {
+	return &apiError{code: "BDC_1105", message: "Incorrect userName or password."}
+}
+
+type handlerFunc func(r *request) (interface{}, *apiError)
+
+// handle parses the request, applies injected errors and writes the response envelope.
+func (s *Server) handle(h handlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, httpReq *http.Request) {
+		path := httpReq.URL.Path[len("/api/v2"):]
+
+		if err := httpReq.ParseForm(); err != nil {
+			writeResponse(w, nil, &apiError{statusCode: http.StatusBadRequest, code: "BDC_1001", message: err.Error()})
+			return
+		}
+
+		req := &request{
+			form: make(map[string]string),
+			data: make(map[string]json.RawMessage),
+		}
+		for key := range httpReq.PostForm {
+			req.form[key] = httpReq.PostForm.Get(key)
+		}
+
+		if data := req.form["data"]; data != "" {
+			if err := json.Unmarshal([]byte(data), &req.data); err != nil {
+				writeResponse(w, nil, &apiError{code: "BDC_1001", message: "Invalid data."})
+				return
+			}
+		}
+
+		if injected := s.record(path); injected != nil {
+			writeResponse(w, nil, &apiError{statusCode: injected.StatusCode, code: injected.Code, message: injected.Message})
+			return
+		}
+
+		data, apiErr := h(req)
+		writeResponse(w, data, apiErr)
+	}
+}
+
+// record counts the request and returns the error injected for the endpoint, if any.
+func (s *Server) record(path string) *InjectedError {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.requests[path]++
+
+	queue := s.errors[path]
+	if len(queue) == 0 {
+		return nil
+	}
+
+	injected := queue[0]
+	injected.Times--
+	if injected.Times <= 0 {
+		s.errors[path] = queue[1:]
+	}
+
+	return injected
+}
+
+func writeResponse(w http.ResponseWriter, data interface{}, apiErr *apiError) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if apiErr != nil {
+		if apiErr.statusCode != 0 {
+			w.WriteHeader(apiErr.statusCode)
+		}
+
+		_ = json.NewEncoder(w).Encode(bill.BaseResponse[bill.ErrorData]{
+			Status:  1,
+			Message: "Error",
+			Data: bill.ErrorData{
+				Code:    apiErr.code,
+				Message: apiErr.message,
+			},
+		})
+
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(bill.BaseResponse[interface{}]{
+		Status:  0,
+		Message: "Success",
+		Data:    data,
+	})
+}
+
+func (s *Server) checkCredentials(r *request) *apiError {
+	if r.form["userName"] != s.fixtures.Username || r.form["password"] != s.fixtures.Password || r.form["devKey"] != s.fixtures.DeveloperKey {
+		return errInvalidCredentials()
+	}
+
+	return nil
+}
+
+// authenticate resolves the organization of the session the request was sent with.
+func (s *Server) authenticate(r *request) *apiError {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if r.form["devKey"] != s.fixtures.DeveloperKey {
+		return errInvalidCredentials()
+	}
+
+	organizationId, ok := s.sessions[r.form["sessionId"]]
+	if !ok {
+		return errInvalidSession()
+	}
+
+	r.organizationId = organizationId
+
+	return nil
+}
+
+func (s *Server) login(r *request) (interface{}, *apiError) {
+	if err := s.checkCredentials(r); err != nil {
+		return nil, err
+	}
+
+	organizationId := r.form["orgId"]
+	if !s.hasOrganization(organizationId) {
+		return nil, errNotFound(organizationId)
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.sessionCount++
+	sessionId := fmt.Sprintf("session-%d", s.sessionCount)
+	s.sessions[sessionId] = organizationId
+
+	// logins from a remembered device are MFA-trusted
+	if deviceId, ok := s.devices[r.form["mfaId"]]; ok && deviceId == r.form["deviceId"] {
+		s.trusted[sessionId] = true
+	}
+
+	return bill.LoginData{
+		SessionId: sessionId,
+		OrgId:     organizationId,
+	}, nil
+}
+
+func (s *Server) logout(r *request) (interface{}, *apiError) {
+	if err := s.authenticate(r); err != nil {
+		return nil, err
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	delete(s.sessions, r.form["sessionId"])
+
+	return map[string]interface{}{}, nil
+}
+
+func (s *Server) hasOrganization(organizationId string) bool {
+	for _, organization := range s.fixtures.Organizations {
+		if organization.Id == organizationId {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *Server) listOrganizations(r *request) (interface{}, *apiError) {
+	if err := s.checkCredentials(r); err != nil {
+		return nil, err
+	}
+
+	return s.fixtures.Organizations, nil
+}
+
+func (s *Server) sessionInfo(r *request) (interface{}, *apiError) {
+	if err := s.authenticate(r); err != nil {
+		return nil, err
+	}
+
+	return bill.SessionDetails{
+		OrgId:  r.organizationId,
+		UserId: s.fixtures.Username,
+	}, nil
+}
+
+func (s *Server) mfaChallenge(r *request) (interface{}, *apiError) {
+	if err := s.authenticate(r); err != nil {
+		return nil, err
+	}
+
+	return bill.MFAChallengeData{ChallengeId: "challenge-" + r.form["sessionId"]}, nil
+}
+
+// mfaAuthenticate trusts the session and remembers the device when the token is the MFAToken of the fixtures.
+func (s *Server) mfaAuthenticate(r *request) (interface{}, *apiError) {
+	if err := s.authenticate(r); err != nil {
+		return nil, err
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if s.fixtures.MFAToken == "" || r.dataString("token") != s.fixtures.MFAToken {
+		return nil, &apiError{code: "BDC_1361", message: "Invalid MFA token."}
+	}
+
+	sessionId := r.form["sessionId"]
+	mfaId := "mfa-" + sessionId
+
+	s.trusted[sessionId] = true
+	s.devices[mfaId] = r.dataString("deviceId")
+
+	return bill.MFAAuthenticateData{MFAId: mfaId}, nil
+}
+
+func (s *Server) mfaStatus(r *request) (interface{}, *apiError) {
+	if err := s.authenticate(r); err != nil {
+		return nil, err
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return bill.MFAStatusData{IsTrusted: s.trusted[r.form["sessionId"]]}, nil
+}
+
+func (s *Server) listUsers(r *request) (interface{}, *apiError) {
+	if err := s.authenticate(r); err != nil {
+		return nil, err
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return listPage(r, s.fixtures.Users[r.organizationId])
+}
+
+// Users returns the current users of the organization, including the changes made through the API.
+func (s *Server) Users(organizationId string) []bill.User {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return append([]bill.User(nil), s.fixtures.Users[organizationId]...)
+}
+
+func (s *Server) readUser(r *request) (interface{}, *apiError) {
+	if err := s.authenticate(r); err != nil {
+		return nil, err
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	id := r.dataString("id")
+	for _, user := range s.fixtures.Users[r.organizationId] {
+		if user.Id == id {
+			return user, nil
+		}
+	}
+
+	return nil, errNotFound(id)
+}
+
+// updateUser replaces the user with the `obj` request data. Like Bill.com, it doesn't do partial updates: the
+// object must hold the required fields.
+func (s *Server) updateUser(r *request) (interface{}, *apiError) {
+	if err := s.authenticate(r); err != nil {
+		return nil, err
+	}
+
+	var user bill.User
+	if err := json.Unmarshal(r.data["obj"], &user); err != nil {
+		return nil, &apiError{code: "BDC_1001", message: "Invalid obj."}
+	}
+
+	if user.Email == "" || user.FirstName == "" || user.LastName == "" || user.RoleId == "" {
+		return nil, &apiError{code: "BDC_1001", message: "firstName, lastName, email and profileId are required."}
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if !s.hasProfile(r.organizationId, user.RoleId) {
+		return nil, &apiError{code: "BDC_1001", message: "Invalid profileId."}
+	}
+
+	users := s.fixtures.Users[r.organizationId]
+	for i := range users {
+		if users[i].Id != user.Id {
+			continue
+		}
+
+		users[i].FirstName = user.FirstName
+		users[i].LastName = user.LastName
+		users[i].Email = user.Email
+		users[i].RoleId = user.RoleId
+		users[i].IsActive = user.IsActive
+
+		return users[i], nil
+	}
+
+	return nil, errNotFound(user.Id)
+}
+
+// createUser adds an active user with the fields of the `obj` request data. Emails are unique in an organization.
+func (s *Server) createUser(r *request) (interface{}, *apiError) {
+	if err := s.authenticate(r); err != nil {
+		return nil, err
+	}
+
+	var user bill.User
+	if err := json.Unmarshal(r.data["obj"], &user); err != nil {
+		return nil, &apiError{code: "BDC_1001", message: "Invalid obj."}
+	}
+
+	if user.Email == "" || user.FirstName == "" || user.LastName == "" {
+		return nil, &apiError{code: "BDC_1001", message: "firstName, lastName and email are required."}
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if !s.hasProfile(r.organizationId, user.RoleId) {
+		return nil, &apiError{code: "BDC_1001", message: "Invalid profileId."}
+	}
+
+	for _, existing := range s.fixtures.Users[r.organizationId] {
+		if strings.EqualFold(existing.Email, user.Email) {
+			return nil, &apiError{code: "BDC_1001", message: fmt.Sprintf("A user with email %s already exists.", user.Email)}
+		}
+	}
+
+	s.userCount++
+	user.Id = fmt.Sprintf("usr-new-%d", s.userCount)
+	user.IsActive = true
+
+	if s.fixtures.Users == nil {
+		s.fixtures.Users = make(map[string][]bill.User)
+	}
+	s.fixtures.Users[r.organizationId] = append(s.fixtures.Users[r.organizationId], user)
+
+	return user, nil
+}
+
+// hasProfile must be called with the lock held.
+func (s *Server) hasProfile(organizationId string, profileId string) bool {
+	for _, profile := range s.fixtures.Profiles[organizationId] {
+		if profile.Id == profileId {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *Server) listProfiles(r *request) (interface{}, *apiError) {
+	if err := s.authenticate(r); err != nil {
+		return nil, err
+	}
+
+	return listPage(r, s.fixtures.Profiles[r.organizationId])
+}
+
+func (s *Server) readProfile(r *request) (interface{}, *apiError) {
+	if err := s.authenticate(r); err != nil {
+		return nil, err
+	}
+
+	id := r.dataString("id")
+	for _, profile := range s.fixtures.Profiles[r.organizationId] {
+		if profile.Id == id {
+			return profile, nil
+		}
+	}
+
+	return nil, errNotFound(id)
+}
+
+func (s *Server) profilePermissions(r *request) (interface{}, *apiError) {
+	if err := s.authenticate(r); err != nil {
+		return nil, err
+	}
+
+	id := r.dataString("id")
+	for _, profile := range s.fixtures.Profiles[r.organizationId] {
+		if profile.Id == id {
+			return s.fixtures.Permissions[id], nil
+		}
+	}
+
+	return nil, errNotFound(id)
+}
+
+func (s *Server) listApprovalPolicies(r *request) (interface{}, *apiError) {
+	if err := s.authenticate(r); err != nil {
+		return nil, err
+	}
+
+	return listPage(r, s.fixtures.ApprovalPolicies[r.organizationId])
+}
+
+func (s *Server) listApprovalPolicyApprovers(r *request) (interface{}, *apiError) {
+	if err := s.authenticate(r); err != nil {
+		return nil, err
+	}
+
+	return listPage(r, s.fixtures.ApprovalPolicyApprovers[r.organizationId])
+}
+
+// listPage returns the page of entities selected by the `start`, `max` and `filters` request data.
+// Like Bill.com, `start` and `max` are required. Only the `=` filter operator is supported. Entities are
+// returned in fixture order.
+func listPage[T any](r *request, entities []T) (interface{}, *apiError) {
+	if _, ok := r.data["start"]; !ok || r.dataInt("max") < 1 {
+		return nil, &apiError{code: "BDC_1001", message: "start and max are required."}
+	}
+
+	var filters []bill.Filter
+	if raw, ok := r.data["filters"]; ok {
+		if err := json.Unmarshal(raw, &filters); err != nil {
+			return nil, &apiError{code: "BDC_1001", message: "Invalid filters."}
+		}
+	}
+
+	matched := make([]T, 0, len(entities))
+	for _, entity := range entities {
+		ok, err := matchFilters(entity, filters)
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			matched = append(matched, entity)
+		}
+	}
+
+	start := r.dataInt("start")
+	if start > len(matched) {
+		start = len(matched)
+	}
+
+	end := len(matched)
+	if limit := r.dataInt("max"); start+limit < end {
+		end = start + limit
+	}
+
+	return matched[start:end], nil
+}
+
+func matchFilters(entity interface{}, filters []bill.Filter) (bool, *apiError) {
+	if len(filters) == 0 {
+		return true, nil
+	}
+
+	raw, err := json.Marshal(entity)
+	if err != nil {
+		return false, &apiError{code: "BDC_1001", message: err.Error()}
+	}
+
+	fields := make(map[string]interface{})
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return false, &apiError{code: "BDC_1001", message: err.Error()}
+	}
+
+	for _, filter := range filters {
+		if filter.Op != "=" {
+			return false, &apiError{code: "BDC_1001", message: fmt.Sprintf("Unsupported filter operator %s.", filter.Op)}
+		}
+
+		if fmt.Sprint(fields[filter.Field]) != fmt.Sprint(filter.Value) {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
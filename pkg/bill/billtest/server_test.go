@@ -0,0 +1,205 @@
+package billtest_test
+
+import (
+	"encoding/json"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ConductorOne/baton-bill/pkg/bill"
+	"github.com/ConductorOne/baton-bill/pkg/bill/billtest"
+)
+
+func newServer(t *testing.T) *billtest.Server {
+	t.Helper()
+
+	server := billtest.NewServer(billtest.DefaultFixtures())
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+// post sends the form to the endpoint path and decodes the response envelope.
+func post(t *testing.T, server *billtest.Server, path string, form url.Values) bill.BaseResponse[json.RawMessage] {
+	t.Helper()
+
+	resp, err := server.Client().PostForm(server.BaseURL()+path, form)
+	if err != nil {
+		t.Fatalf("PostForm %s: %v", path, err)
+	}
+	defer resp.Body.Close()
+
+	var response bill.BaseResponse[json.RawMessage]
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		t.Fatalf("decoding the response of %s: %v", path, err)
+	}
+
+	return response
+}
+
+// login logs in to the organization and returns the session id.
+func login(t *testing.T, server *billtest.Server, organizationId string) string {
+	t.Helper()
+
+	fixtures := billtest.DefaultFixtures()
+
+	response := post(t, server, "/Login.json", url.Values{
+		"userName": {fixtures.Username},
+		"password": {fixtures.Password},
+		"devKey":   {fixtures.DeveloperKey},
+		"orgId":    {organizationId},
+	})
+
+	var data bill.LoginData
+	if err := json.Unmarshal(response.Data, &data); err != nil || response.Status != 0 {
+		t.Fatalf("Login: got %s", response.Data)
+	}
+
+	return data.SessionId
+}
+
+// listUsers sends the list request with the data and returns the ids of the users, or the error code.
+func listUsers(t *testing.T, server *billtest.Server, sessionId string, data string) ([]string, string) {
+	t.Helper()
+
+	response := post(t, server, "/List/User.json", url.Values{
+		"devKey":    {billtest.DefaultFixtures().DeveloperKey},
+		"sessionId": {sessionId},
+		"data":      {data},
+	})
+
+	if response.Status != 0 {
+		var errData bill.ErrorData
+		if err := json.Unmarshal(response.Data, &errData); err != nil {
+			t.Fatalf("decoding the error: %v", err)
+		}
+
+		return nil, errData.Code
+	}
+
+	var users []bill.User
+	if err := json.Unmarshal(response.Data, &users); err != nil {
+		t.Fatalf("decoding the users: %v", err)
+	}
+
+	ids := make([]string, 0, len(users))
+	for _, user := range users {
+		ids = append(ids, user.Id)
+	}
+
+	return ids, ""
+}
+
+func TestServerRequiresStartAndMax(t *testing.T) {
+	server := newServer(t)
+	sessionId := login(t, server, "org-1")
+
+	for _, data := range []string{`{}`, `{"start":0}`, `{"max":10}`} {
+		if _, code := listUsers(t, server, sessionId, data); code != "BDC_1001" {
+			t.Errorf("got error code %q listing with %s, want BDC_1001", code, data)
+		}
+	}
+}
+
+func TestServerFiltersAndPagesLists(t *testing.T) {
+	server := newServer(t)
+	sessionId := login(t, server, "org-1")
+
+	tests := []struct {
+		data string
+		want []string
+	}{
+		{`{"start":0,"max":10}`, []string{"usr-1", "usr-2", "usr-3"}},
+		{`{"start":1,"max":1}`, []string{"usr-2"}},
+		{`{"start":5,"max":10}`, []string{}},
+		{`{"start":0,"max":10,"filters":[{"field":"email","op":"=","value":"carl@example.com"}]}`, []string{"usr-2"}},
+		{`{"start":0,"max":10,"filters":[{"field":"isActive","op":"=","value":"2"}]}`, []string{"usr-3"}},
+	}
+
+	for _, tt := range tests {
+		got, code := listUsers(t, server, sessionId, tt.data)
+		if code != "" {
+			t.Errorf("got error code %s listing with %s", code, tt.data)
+			continue
+		}
+
+		if len(got) != len(tt.want) {
+			t.Errorf("got %v listing with %s, want %v", got, tt.data, tt.want)
+			continue
+		}
+
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("got %v listing with %s, want %v", got, tt.data, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestServerScopesSessionsToTheirOrganization(t *testing.T) {
+	server := newServer(t)
+
+	got, code := listUsers(t, server, login(t, server, "org-2"), `{"start":0,"max":10}`)
+	if code != "" || len(got) != 1 || got[0] != "usr-4" {
+		t.Errorf("got %v, %q, want only the user of org-2", got, code)
+	}
+}
+
+func TestServerFailsInjectedErrorTimes(t *testing.T) {
+	server := newServer(t)
+	sessionId := login(t, server, "org-1")
+
+	server.InjectError("/List/User.json", billtest.InjectedError{Code: "BDC_1144", Message: "Too many requests.", Times: 2})
+
+	for i := 0; i < 2; i++ {
+		if _, code := listUsers(t, server, sessionId, `{"start":0,"max":10}`); code != "BDC_1144" {
+			t.Errorf("got error code %q for request %d, want the injected BDC_1144", code, i)
+		}
+	}
+
+	if _, code := listUsers(t, server, sessionId, `{"start":0,"max":10}`); code != "" {
+		t.Errorf("got error code %s after the injected errors", code)
+	}
+
+	if got := server.Requests("/List/User.json"); got != 3 {
+		t.Errorf("got %d requests, want 3", got)
+	}
+}
+
+func TestServerExpireSessions(t *testing.T) {
+	server := newServer(t)
+	sessionId := login(t, server, "org-1")
+
+	server.ExpireSessions()
+
+	if server.Sessions() != 0 {
+		t.Errorf("got %d sessions, want none", server.Sessions())
+	}
+
+	if _, code := listUsers(t, server, sessionId, `{"start":0,"max":10}`); code != bill.ErrCodeInvalidSession {
+		t.Errorf("got error code %q, want %s", code, bill.ErrCodeInvalidSession)
+	}
+}
+
+func TestLoadFixtures(t *testing.T) {
+	data, err := json.Marshal(billtest.DefaultFixtures())
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "fixtures.json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	fixtures, err := billtest.LoadFixtures(path)
+	if err != nil {
+		t.Fatalf("LoadFixtures: %v", err)
+	}
+
+	if fixtures.Username != "integration@example.com" || len(fixtures.Users["org-1"]) != 3 || !fixtures.Permissions["pro-1"]["payBills"] {
+		t.Errorf("got fixtures %+v, want the default fixtures", fixtures)
+	}
+}
@@ -3,25 +3,33 @@ package bill
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
-	"net/url"
 	"strings"
 
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
 )
 
 const SandboxBaseURL = "https://api-sandbox.bill.com/api/v2"
 const BaseURL = "https://api.bill.com/api/v2"
 
+const (
+	EnvironmentProduction = "production"
+	EnvironmentSandbox    = "sandbox"
+)
+
 // usual format: <API_Base_URL>/Crud/<Operation>/<Entity>.json (Read more at https://developer.bill.com/docs/api-request-format)
-const LoginBaseURL = BaseURL + "/Login.json"
-const UsersBaseURL = BaseURL + "/List/User.json"
-const OrganizationsBaseURL = BaseURL + "/ListOrgs.json"
-const UserRoleProfileBaseURL = BaseURL + "/Crud/Read/Profile.json"
-const UserRoleProfilesBaseURL = BaseURL + "/List/Profile.json"
-const UserRolePermissionsBaseURL = BaseURL + "/GetProfilePermissions.json"
-const ApiSessionBaseURL = BaseURL + "/GetSessionInfo.json"
+const loginPath = "/Login.json"
+const organizationsPath = "/ListOrgs.json"
+const userRolePermissionsPath = "/GetProfilePermissions.json"
+const apiSessionPath = "/GetSessionInfo.json"
+const mfaChallengePath = "/MFAChallenge.json"
+const mfaAuthenticatePath = "/MFAAuthenticate.json"
+const mfaStatusPath = "/MFAStatus.json"
+const logoutPath = "/Logout.json"
 
 type Credentials struct {
 	Username       string
@@ -29,19 +37,61 @@ type Credentials struct {
 	OrganizationId string
 	DeveloperKey   string
 	SessionId      string
+	// MFAId and DeviceId identify a device remembered by a previous MFA authentication.
+	MFAId    string
+	DeviceId string
 }
 
 type Client struct {
 	httpClient *http.Client
+	baseURL    string
+	sessions   *SessionManager
+	limiter    *rateLimiter
+	cache      SessionCache
+	redactor   *Redactor
+	dryRun     bool
 	Credentials
 }
 
+// ClientOption configures optional behavior of the Client.
+type ClientOption func(*Client)
+
+// WithBaseURL sends the requests to the provided Bill.com API base URL instead of the production one.
+func WithBaseURL(baseURL string) ClientOption {
+	return func(c *Client) {
+		c.baseURL = strings.TrimSuffix(baseURL, "/")
+	}
+}
+
+// BaseURLForEnvironment returns the API base URL of the Bill.com environment.
+func BaseURLForEnvironment(environment string) (string, error) {
+	switch environment {
+	case EnvironmentProduction:
+		return BaseURL, nil
+	case EnvironmentSandbox:
+		return SandboxBaseURL, nil
+	default:
+		return "", fmt.Errorf("bill: unknown environment %q", environment)
+	}
+}
+
+// WithSessionCache reuses sessions stored in the cache by previous runs and stores new sessions in it.
+func WithSessionCache(cache SessionCache) ClientOption {
+	return func(c *Client) {
+		c.cache = cache
+	}
+}
+
+// WithRateLimit sets the limits the client applies to requests sent to the Bill.com API.
+func WithRateLimit(config RateLimitConfig) ClientOption {
+	return func(c *Client) {
+		c.limiter = newRateLimiter(config)
+	}
+}
+
 type LoginResponse = BaseResponse[LoginData]
-type UsersResponse = BaseResponse[[]User]
 type SessionDetailsResponse = BaseResponse[SessionDetails]
 type OrganizationsResponse = BaseResponse[[]Organization]
-type UserRoleProfileResponse = BaseResponse[UserRoleProfile]
-type UserRoleProfilesResponse = BaseResponse[[]UserRoleProfile]
 type UserRolePermissionsResponse = BaseResponse[map[string]bool]
 
 type UserParams struct {
@@ -49,187 +99,360 @@ type UserParams struct {
 	SearchParams
 }
 
-func NewClient(httpClient *http.Client, credentials Credentials) *Client {
-	return &Client{
+func NewClient(httpClient *http.Client, credentials Credentials, opts ...ClientOption) *Client {
+	c := &Client{
 		httpClient:  httpClient,
+		baseURL:     BaseURL,
+		sessions:    NewSessionManager(),
+		limiter:     newRateLimiter(DefaultRateLimitConfig()),
+		redactor:    NewRedactor(),
 		Credentials: credentials,
 	}
-}
-
-// Login logs the user into specific organization and returns the session id and organization id.
-func (c *Client) Login(ctx context.Context, organizationId string) error {
-	var loginResponse LoginResponse
-
-	// Setup required organization id for client
-	c.Credentials.OrganizationId = organizationId
-
-	err := c.doRequest(ctx, UsersBaseURL, &loginResponse, c.Credentials, nil, nil)
 
-	if err != nil {
-		return err
-	}
-
-	if IsInvalidResponse(loginResponse) {
-		return status.Error(400, "Request failed")
+	for _, opt := range opts {
+		opt(c)
 	}
 
-	// modify the client credentials to involve new session id and organization id
-	c.Credentials.SessionId = loginResponse.Data.SessionId
-	c.Credentials.OrganizationId = loginResponse.Data.OrgId
+	return c
+}
 
-	return nil
+// RateLimitStatus returns the current state of the request budget of the client.
+func (c *Client) RateLimitStatus() RateLimitStatus {
+	return c.limiter.status()
 }
 
-// GetOrganization returns detail information about the organization.
-// This operation does not require Login to be called first.
-func (c *Client) GetOrganizations(ctx context.Context) ([]Organization, error) {
-	var organizationsResponse OrganizationsResponse
+// Login logs the user into specific organization and stores the new session in the session manager.
+// When the credentials hold a remembered MFA id and device id, the session is checked for MFA trust.
+func (c *Client) Login(ctx context.Context, organizationId string) (*Session, error) {
+	var loginResponse LoginResponse
 
 	err := c.doRequest(
 		ctx,
-		OrganizationsBaseURL,
-		&organizationsResponse,
+		"",
+		loginPath,
+		&loginResponse,
 		Credentials{
-			DeveloperKey: c.DeveloperKey,
-			Username:     c.Username,
-			Password:     c.Password,
+			DeveloperKey:   c.DeveloperKey,
+			Username:       c.Username,
+			Password:       c.Password,
+			OrganizationId: organizationId,
+			MFAId:          c.MFAId,
+			DeviceId:       c.DeviceId,
 		},
-		nil,
-		nil,
 	)
 
 	if err != nil {
 		return nil, err
 	}
 
-	if IsInvalidResponse(organizationsResponse) {
-		return nil, status.Error(400, "Request failed")
+	session := &Session{
+		Id:             loginResponse.Data.SessionId,
+		OrganizationId: organizationId,
 	}
 
-	return organizationsResponse.Data, nil
+	if c.MFAId != "" {
+		mfaStatus, err := c.getMFAStatus(ctx, session)
+		if err != nil {
+			return nil, err
+		}
+
+		session.MFATrusted = mfaStatus.IsTrusted
+	}
+
+	c.sessions.Set(session)
+	c.cacheSession(ctx, session)
+
+	return session, nil
 }
 
-// GetSessionDetails returns details regarding session of currently signed in user and organization.
-func (c *Client) GetSessionDetails(ctx context.Context) (SessionDetails, error) {
-	var sessionDetailsResponse SessionDetailsResponse
+// cacheSession stores the session in the session cache, if there is one, for the next runs to reuse.
+// Failures are only logged, since the session can still be used by this run.
+func (c *Client) cacheSession(ctx context.Context, session *Session) {
+	if c.cache == nil {
+		return
+	}
 
-	err := c.doRequest(
+	err := c.cache.Store(c.sessionCacheKey(session.OrganizationId), session)
+	if err != nil {
+		ctxzap.Extract(ctx).Warn("bill: failed to store session in cache", zap.String("organization_id", session.OrganizationId), zap.Error(err))
+	}
+}
+
+func (c *Client) getMFAStatus(ctx context.Context, session *Session) (MFAStatusData, error) {
+	var mfaStatusResponse BaseResponse[MFAStatusData]
+
+	err := c.send(
 		ctx,
-		UsersBaseURL,
-		&sessionDetailsResponse,
-		Credentials{
-			DeveloperKey: c.DeveloperKey,
-			SessionId:    c.SessionId,
-		},
-		nil,
-		nil,
+		mfaStatusPath,
+		&mfaStatusResponse,
+		c.withSession(session, []RequestOption{
+			RequestData{"mfaId": c.MFAId, "deviceId": c.DeviceId},
+		})...,
 	)
 
-	if err != nil {
-		return SessionDetails{}, err
-	}
+	return mfaStatusResponse.Data, err
+}
+
+// MFAChallenge sends a multi-factor authentication code to the user and returns the id of the challenge.
+func (c *Client) MFAChallenge(ctx context.Context, organizationId string, useBackup bool) (string, error) {
+	var mfaChallengeResponse BaseResponse[MFAChallengeData]
 
-	if IsInvalidResponse(sessionDetailsResponse) {
-		return SessionDetails{}, status.Error(400, "Request failed")
+	err := c.doRequest(
+		ctx,
+		organizationId,
+		mfaChallengePath,
+		&mfaChallengeResponse,
+		RequestData{"useBackup": useBackup},
+	)
+	if err != nil {
+		return "", err
 	}
 
-	return sessionDetailsResponse.Data, nil
+	return mfaChallengeResponse.Data.ChallengeId, nil
 }
 
-// GetUsers returns all users under the organization account.
-func (c *Client) GetUsers(ctx context.Context, getUsersVars PaginationParams) ([]User, int, error) {
-	var usersResponse UsersResponse
+// MFAAuthenticate answers the challenge with the code the user received and remembers the device,
+// so later logins with the returned MFA id and the device id create MFA-trusted sessions.
+func (c *Client) MFAAuthenticate(ctx context.Context, organizationId string, challengeId string, token string, deviceId string, machineName string) (string, error) {
+	var mfaAuthenticateResponse BaseResponse[MFAAuthenticateData]
 
 	err := c.doRequest(
 		ctx,
-		UsersBaseURL,
-		&usersResponse,
-		Credentials{
-			DeveloperKey: c.DeveloperKey,
-			SessionId:    c.SessionId,
+		organizationId,
+		mfaAuthenticatePath,
+		&mfaAuthenticateResponse,
+		RequestData{
+			"challengeId": challengeId,
+			"token":       token,
+			"deviceId":    deviceId,
+			"machineName": machineName,
+			"rememberMe":  true,
 		},
-		getUsersVars,
-		nil,
 	)
+	if err != nil {
+		return "", err
+	}
+
+	c.sessions.MarkMFATrusted(organizationId)
+
+	// the cached session must be trusted as well, or the next run would reuse it untrusted
+	if session, ok := c.sessions.Get(organizationId); ok {
+		c.cacheSession(ctx, session)
+	}
+
+	return mfaAuthenticateResponse.Data.MFAId, nil
+}
+
+// Logout ends the session of the organization and removes it from the session manager.
+func (c *Client) Logout(ctx context.Context, organizationId string) error {
+	session, ok := c.sessions.Get(organizationId)
+	if !ok {
+		return nil
+	}
+
+	c.sessions.Delete(organizationId)
+
+	var logoutResponse BaseResponse[json.RawMessage]
 
+	return c.send(ctx, logoutPath, &logoutResponse, c.withSession(session, nil)...)
+}
+
+// Close logs out of every session the client holds, so they don't count against the concurrent session limits.
+// Sessions are kept open when a session cache is used, so the next run can reuse them.
+func (c *Client) Close(ctx context.Context) error {
+	if c.cache != nil {
+		return nil
+	}
+
+	var rv error
+	for _, session := range c.sessions.All() {
+		err := c.Logout(ctx, session.OrganizationId)
+		if err != nil {
+			rv = multierr.Append(rv, fmt.Errorf("bill: failed to logout of organization %s: %w", session.OrganizationId, err))
+		}
+	}
+
+	return rv
+}
+
+// session returns the session for the organization, logging in lazily if there is none yet.
+func (c *Client) session(ctx context.Context, organizationId string) (*Session, error) {
+	unlock := c.sessions.lockOrganization(organizationId)
+	defer unlock()
+
+	if session, ok := c.sessions.Get(organizationId); ok {
+		return session, nil
+	}
+
+	if session, ok := c.cachedSession(ctx, organizationId); ok {
+		c.sessions.Set(session)
+		return session, nil
+	}
+
+	return c.Login(ctx, organizationId)
+}
+
+func (c *Client) sessionCacheKey(organizationId string) string {
+	return SessionCacheKey(c.baseURL, c.Username, c.DeveloperKey, organizationId)
+}
+
+// cachedSession returns the session stored in the cache by a previous run, if it is still valid.
+// Stale sessions are removed from the cache.
+func (c *Client) cachedSession(ctx context.Context, organizationId string) (*Session, bool) {
+	if c.cache == nil {
+		return nil, false
+	}
+
+	l := ctxzap.Extract(ctx).With(zap.String("organization_id", organizationId))
+	key := c.sessionCacheKey(organizationId)
+
+	session, ok, err := c.cache.Load(key)
 	if err != nil {
-		return nil, 0, err
+		l.Warn("bill: failed to load session from cache", zap.Error(err))
+		return nil, false
+	}
+
+	if !ok {
+		return nil, false
 	}
 
-	if IsInvalidResponse(usersResponse) {
-		return nil, 0, status.Error(400, "Request failed")
+	var sessionDetailsResponse SessionDetailsResponse
+
+	err = c.send(ctx, apiSessionPath, &sessionDetailsResponse, c.withSession(session, nil)...)
+	if err == nil && sessionDetailsResponse.Data.OrgId == organizationId {
+		return session, true
 	}
 
-	return usersResponse.Data, getUsersVars.Start + getUsersVars.Max, nil
+	l.Debug("bill: cached session is stale", zap.Error(err))
+
+	err = c.cache.Delete(key)
+	if err != nil {
+		l.Warn("bill: failed to remove stale session from cache", zap.Error(err))
+	}
+
+	return nil, false
 }
 
-// GetUserRoleProfiles returns all user roles available in the organization.
-func (c *Client) GetUserRoleProfiles(ctx context.Context, getUserRoleProfilesVars PaginationParams) ([]UserRoleProfile, int, error) {
-	var userRoleProfilesResponse UserRoleProfilesResponse
+// GetOrganization returns detail information about the organization.
+// This operation does not require Login to be called first.
+func (c *Client) GetOrganizations(ctx context.Context) ([]Organization, error) {
+	var organizationsResponse OrganizationsResponse
 
 	err := c.doRequest(
 		ctx,
-		UserRoleProfilesBaseURL,
-		&userRoleProfilesResponse,
+		"",
+		organizationsPath,
+		&organizationsResponse,
 		Credentials{
 			DeveloperKey: c.DeveloperKey,
-			SessionId:    c.SessionId,
+			Username:     c.Username,
+			Password:     c.Password,
 		},
-		getUserRoleProfilesVars,
-		nil,
 	)
 
 	if err != nil {
-		return nil, 0, err
-	}
-
-	if IsInvalidResponse(userRoleProfilesResponse) {
-		return nil, 0, status.Error(400, "Request failed")
+		return nil, err
 	}
 
-	return userRoleProfilesResponse.Data, getUserRoleProfilesVars.Start + getUserRoleProfilesVars.Max, nil
+	return organizationsResponse.Data, nil
 }
 
-// GetUserRoleProfile returns detail information about the user role under provided id.
-func (c *Client) GetUserRoleProfile(ctx context.Context, roleId string) (UserRoleProfile, error) {
-	var userRoleProfileResponse UserRoleProfileResponse
+// GetSessionDetails returns details regarding session of currently signed in user and organization.
+func (c *Client) GetSessionDetails(ctx context.Context, organizationId string) (SessionDetails, error) {
+	var sessionDetailsResponse SessionDetailsResponse
 
 	err := c.doRequest(
 		ctx,
-		UserRoleProfileBaseURL,
-		&userRoleProfileResponse,
-		Credentials{
-			DeveloperKey: c.DeveloperKey,
-			SessionId:    c.SessionId,
-		},
-		nil,
-		SearchParams{Id: roleId},
+		organizationId,
+		apiSessionPath,
+		&sessionDetailsResponse,
 	)
 
 	if err != nil {
-		return UserRoleProfile{}, err
+		return SessionDetails{}, err
 	}
 
-	if IsInvalidResponse(userRoleProfileResponse) {
-		return UserRoleProfile{}, status.Error(400, "Request failed")
-	}
+	return sessionDetailsResponse.Data, nil
+}
+
+// GetUsers returns a page of users under the organization account.
+func (c *Client) GetUsers(ctx context.Context, organizationId string, getUsersVars UserParams) ([]User, error) {
+	return List[User](ctx, c, organizationId, EntityUser, getUsersVars)
+}
 
-	return userRoleProfileResponse.Data, nil
+// GetUser returns the user of the organization with the provided id.
+func (c *Client) GetUser(ctx context.Context, organizationId string, userId string) (User, error) {
+	return Read[User](ctx, c, organizationId, EntityUser, userId)
+}
+
+// GetUsersByEmail returns a page of the users of the organization with the provided email, active or not.
+func (c *Client) GetUsersByEmail(ctx context.Context, organizationId string, email string, params PaginationParams) ([]User, error) {
+	return List[User](ctx, c, organizationId, EntityUser, UserParams{
+		PaginationParams: params,
+		SearchParams: SearchParams{
+			Filters: []Filter{{Field: "email", Op: "=", Value: email}},
+			Sort:    []Sort{{Field: "id", Asc: true}},
+		},
+	})
+}
+
+// CreateUser creates a user in the organization. Bill.com emails the user an invitation to set a password.
+func (c *Client) CreateUser(ctx context.Context, organizationId string, user UserCreate) (User, error) {
+	return write[User](ctx, c, organizationId, "Create", EntityUser, user)
+}
+
+// UpdateUser replaces the user with the provided one and returns the updated user. Bill.com expects the full
+// user, with its required fields, so change the user returned by GetUser or GetUsers.
+func (c *Client) UpdateUser(ctx context.Context, organizationId string, user User) (User, error) {
+	return Update[User](ctx, c, organizationId, EntityUser, user)
+}
+
+// GetUserRoleProfiles returns a page of user roles available in the organization.
+func (c *Client) GetUserRoleProfiles(ctx context.Context, organizationId string, getUserRoleProfilesVars PaginationParams) ([]UserRoleProfile, error) {
+	return List[UserRoleProfile](ctx, c, organizationId, EntityProfile, UserParams{
+		PaginationParams: getUserRoleProfilesVars,
+		SearchParams: SearchParams{
+			Sort: []Sort{{Field: "id", Asc: true}},
+		},
+	})
+}
+
+// GetUserRoleProfile returns detail information about the user role under provided id.
+func (c *Client) GetUserRoleProfile(ctx context.Context, organizationId string, roleId string) (UserRoleProfile, error) {
+	return Read[UserRoleProfile](ctx, c, organizationId, EntityProfile, roleId)
+}
+
+// GetApprovalPolicies returns a page of approval policies of the organization.
+func (c *Client) GetApprovalPolicies(ctx context.Context, organizationId string, params PaginationParams) ([]ApprovalPolicy, error) {
+	return List[ApprovalPolicy](ctx, c, organizationId, EntityApprovalPolicy, UserParams{
+		PaginationParams: params,
+		SearchParams: SearchParams{
+			Sort: []Sort{{Field: "id", Asc: true}},
+		},
+	})
+}
+
+// GetApprovalPolicyApprovers returns a page of approvers of the approval policy, in approval order. Approvers
+// with the same sort order are ordered by id so that pages don't overlap.
+func (c *Client) GetApprovalPolicyApprovers(ctx context.Context, organizationId string, approvalPolicyId string, params PaginationParams) ([]ApprovalPolicyApprover, error) {
+	return List[ApprovalPolicyApprover](ctx, c, organizationId, EntityApprovalPolicyApprover, UserParams{
+		PaginationParams: params,
+		SearchParams: SearchParams{
+			Filters: []Filter{{Field: "approvalPolicyId", Op: "=", Value: approvalPolicyId}},
+			Sort:    []Sort{{Field: "sortOrder", Asc: true}, {Field: "id", Asc: true}},
+		},
+	})
 }
 
 // GetUserRolePermissions returns map of permissions under the provided user role.
-func (c *Client) GetUserRolePermissions(ctx context.Context, roleId string) (map[string]bool, error) {
+func (c *Client) GetUserRolePermissions(ctx context.Context, organizationId string, roleId string) (map[string]bool, error) {
 	var userRolePermissionsResponse UserRolePermissionsResponse
 
 	err := c.doRequest(
 		ctx,
-		UserRolePermissionsBaseURL,
+		organizationId,
+		userRolePermissionsPath,
 		&userRolePermissionsResponse,
-		Credentials{
-			DeveloperKey: c.DeveloperKey,
-			SessionId:    c.SessionId,
-		},
-		nil,
 		SearchParams{Id: roleId},
 	)
 
@@ -237,28 +460,116 @@ func (c *Client) GetUserRolePermissions(ctx context.Context, roleId string) (map
 		return nil, err
 	}
 
-	if IsInvalidResponse(userRolePermissionsResponse) {
-		return nil, status.Error(400, "Request failed")
-	}
-
 	return userRolePermissionsResponse.Data, nil
 }
 
+// doRequest sends the request to the endpoint path of the Bill.com API. When organizationId is set, the request is sent
+// with the session of that organization, logging in first if needed. If the session turns out to be
+// expired, the client logs into the organization again and replays the request once.
 func (c *Client) doRequest(
 	ctx context.Context,
-	urlAddress string,
+	organizationId string,
+	path string,
+	resourceResponse interface{},
+	requestOptions ...RequestOption,
+) error {
+	if organizationId == "" {
+		return c.send(ctx, path, resourceResponse, requestOptions...)
+	}
+
+	session, err := c.session(ctx, organizationId)
+	if err != nil {
+		return err
+	}
+
+	err = c.send(ctx, path, resourceResponse, c.withSession(session, requestOptions)...)
+	if !IsInvalidSessionError(err) {
+		return err
+	}
+
+	session, err = c.relogin(ctx, session)
+	if err != nil {
+		return fmt.Errorf("bill: failed to login to organization %s again after the session expired: %w", organizationId, err)
+	}
+
+	return c.send(ctx, path, resourceResponse, c.withSession(session, requestOptions)...)
+}
+
+// withSession returns a copy of the request options with the session credentials added.
+func (c *Client) withSession(session *Session, requestOptions []RequestOption) []RequestOption {
+	rv := make([]RequestOption, 0, len(requestOptions)+1)
+	rv = append(rv, requestOptions...)
+	rv = append(rv, Credentials{
+		DeveloperKey: c.DeveloperKey,
+		SessionId:    session.Id,
+	})
+
+	return rv
+}
+
+// relogin replaces the expired session with a new one, unless another request already did so.
+func (c *Client) relogin(ctx context.Context, expired *Session) (*Session, error) {
+	unlock := c.sessions.lockOrganization(expired.OrganizationId)
+	defer unlock()
+
+	if session, ok := c.sessions.Get(expired.OrganizationId); ok && session.Id != expired.Id {
+		return session, nil
+	}
+
+	c.sessions.Delete(expired.OrganizationId)
+
+	return c.Login(ctx, expired.OrganizationId)
+}
+
+// send posts the request to the Bill.com API within the rate limits, retrying with backoff
+// when the API reports that the limits were exceeded.
+func (c *Client) send(
+	ctx context.Context,
+	path string,
 	resourceResponse interface{},
 	requestOptions ...RequestOption,
 ) error {
-	requestBody := url.Values{}
+	for attempt := 0; ; attempt++ {
+		release, err := c.limiter.acquire(ctx)
+		if err != nil {
+			return err
+		}
+
+		err = c.sendOnce(ctx, path, resourceResponse, requestOptions...)
+		release()
+
+		if !IsRateLimitError(err) || attempt >= c.limiter.config.MaxRetries {
+			return err
+		}
+
+		err = sleep(ctx, c.limiter.backoff(attempt))
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// sendOnce encodes the request options into the form body and posts it to the Bill.com API.
+func (c *Client) sendOnce(
+	ctx context.Context,
+	path string,
+	resourceResponse interface{},
+	requestOptions ...RequestOption,
+) error {
+	requestBody := newRequestBody()
 
 	for _, option := range requestOptions {
 		if option != nil {
-			option.Apply(&requestBody)
+			option.Apply(requestBody)
 		}
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, urlAddress, strings.NewReader(requestBody.Encode()))
+	encodedBody, err := requestBody.Encode()
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, strings.NewReader(encodedBody))
 	if err != nil {
 		return err
 	}
@@ -272,15 +583,10 @@ func (c *Client) doRequest(
 
 	defer rawResponse.Body.Close()
 
-	if rawResponse.StatusCode >= 300 {
-		return status.Error(codes.Code(rawResponse.StatusCode), "Request failed")
-	}
-
-	// TODO: check if this works because in case of error, the users in data field
-	//  	 won't be present, ther will be an error data instead
-	if err := json.NewDecoder(rawResponse.Body).Decode(&resourceResponse); err != nil {
+	body, err := io.ReadAll(rawResponse.Body)
+	if err != nil {
 		return err
 	}
 
-	return nil
+	return decodeResponse(path, rawResponse.StatusCode, body, resourceResponse)
 }
@@ -0,0 +1,420 @@
+package bill_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ConductorOne/baton-bill/pkg/bill"
+	"github.com/ConductorOne/baton-bill/pkg/bill/billtest"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// newTestClient starts a fake Bill.com API with the default fixtures and returns a client logged in to nothing yet.
+func newTestClient(t *testing.T, opts ...bill.ClientOption) (*billtest.Server, *bill.Client) {
+	t.Helper()
+
+	fixtures := billtest.DefaultFixtures()
+
+	server := billtest.NewServer(fixtures)
+	t.Cleanup(server.Close)
+
+	client := bill.NewClient(
+		server.Client(),
+		bill.Credentials{
+			Username:     fixtures.Username,
+			Password:     fixtures.Password,
+			DeveloperKey: fixtures.DeveloperKey,
+		},
+		append([]bill.ClientOption{bill.WithBaseURL(server.BaseURL())}, opts...)...,
+	)
+
+	return server, client
+}
+
+func firstPage() bill.UserParams {
+	return bill.UserParams{PaginationParams: bill.PaginationParams{Max: 50}}
+}
+
+func TestClientLogsInAgainAfterSessionExpired(t *testing.T) {
+	ctx := context.Background()
+	server, client := newTestClient(t)
+
+	if _, err := client.GetUsers(ctx, "org-1", firstPage()); err != nil {
+		t.Fatalf("GetUsers: %v", err)
+	}
+
+	server.ExpireSessions()
+
+	users, err := client.GetUsers(ctx, "org-1", firstPage())
+	if err != nil {
+		t.Fatalf("GetUsers after the session expired: %v", err)
+	}
+
+	if len(users) != 3 {
+		t.Errorf("got %d users, want 3", len(users))
+	}
+
+	if got := server.Requests("/Login.json"); got != 2 {
+		t.Errorf("got %d logins, want 2", got)
+	}
+}
+
+func TestClientRetriesRateLimitedRequests(t *testing.T) {
+	tests := []struct {
+		name     string
+		injected billtest.InjectedError
+	}{
+		{
+			name:     "error code",
+			injected: billtest.InjectedError{Code: bill.ErrCodeTooManyRequests, Message: "Max requests exceeded.", Times: 2},
+		},
+		{
+			name:     "http status",
+			injected: billtest.InjectedError{StatusCode: http.StatusTooManyRequests, Times: 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			server, client := newTestClient(t, bill.WithRateLimit(bill.RateLimitConfig{
+				MaxRetries:  3,
+				BaseBackoff: time.Millisecond,
+				MaxBackoff:  5 * time.Millisecond,
+			}))
+
+			server.InjectError("/List/User.json", tt.injected)
+
+			if _, err := client.GetUsers(ctx, "org-1", firstPage()); err != nil {
+				t.Fatalf("GetUsers: %v", err)
+			}
+
+			if got := server.Requests("/List/User.json"); got != 3 {
+				t.Errorf("got %d requests, want 3", got)
+			}
+		})
+	}
+}
+
+func TestClientGivesUpAfterMaxRetries(t *testing.T) {
+	ctx := context.Background()
+	server, client := newTestClient(t, bill.WithRateLimit(bill.RateLimitConfig{
+		MaxRetries:  2,
+		BaseBackoff: time.Millisecond,
+		MaxBackoff:  5 * time.Millisecond,
+	}))
+
+	server.InjectError("/List/User.json", billtest.InjectedError{Code: bill.ErrCodeTooManyRequests, Times: 10})
+
+	_, err := client.GetUsers(ctx, "org-1", firstPage())
+	if !bill.IsRateLimitError(err) {
+		t.Fatalf("got error %v, want a rate limit error", err)
+	}
+
+	if got := server.Requests("/List/User.json"); got != 3 {
+		t.Errorf("got %d requests, want 3", got)
+	}
+}
+
+func TestClientDryRunDoesNotSendWrites(t *testing.T) {
+	ctx := context.Background()
+	server, client := newTestClient(t, bill.WithDryRun(true))
+
+	user, err := client.GetUser(ctx, "org-1", "usr-2")
+	if err != nil {
+		t.Fatalf("GetUser: %v", err)
+	}
+
+	user.RoleId = "pro-1"
+
+	updated, err := client.UpdateUser(ctx, "org-1", user)
+	if err != nil {
+		t.Fatalf("UpdateUser: %v", err)
+	}
+
+	if updated.RoleId != "pro-1" {
+		t.Errorf("got planned role %q, want pro-1", updated.RoleId)
+	}
+
+	_, err = client.CreateUser(ctx, "org-1", bill.UserCreate{FirstName: "Eve", LastName: "New", Email: "eve@example.com", RoleId: "pro-2"})
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+
+	for _, path := range []string{"/Crud/Update/User.json", "/Crud/Create/User.json"} {
+		if got := server.Requests(path); got != 0 {
+			t.Errorf("got %d requests to %s, want none", got, path)
+		}
+	}
+
+	users := server.Users("org-1")
+	if len(users) != 3 || users[1].RoleId != "pro-2" {
+		t.Errorf("dry run changed the users: %+v", users)
+	}
+}
+
+func TestClientDryRunLogsWhoWouldBeCreated(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	ctx := ctxzap.ToContext(context.Background(), zap.New(core))
+	_, client := newTestClient(t, bill.WithDryRun(true))
+
+	_, err := client.CreateUser(ctx, "org-1", bill.UserCreate{FirstName: "Eve", LastName: "New", Email: "eve@example.com", RoleId: "pro-2"})
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+
+	planned := logs.FilterField(zap.String("endpoint", "/Crud/Create/User.json")).All()
+	if len(planned) != 1 {
+		t.Fatalf("got %d planned creations logged, want 1", len(planned))
+	}
+
+	logged := fmt.Sprint(planned[0].ContextMap())
+	if !strings.Contains(logged, "eve@example.com") || !strings.Contains(logged, "Eve") {
+		t.Errorf("got %s, want the user to create", logged)
+	}
+
+	if strings.Contains(logged, "session-1") {
+		t.Errorf("got %s, want the session id masked", logged)
+	}
+}
+
+// memorySessionCache is a SessionCache shared by the clients of a test, like the cache file between runs.
+type memorySessionCache struct {
+	mtx      sync.Mutex
+	sessions map[string]bill.Session
+}
+
+func (c *memorySessionCache) Load(key string) (*bill.Session, bool, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	session, ok := c.sessions[key]
+
+	return &session, ok, nil
+}
+
+func (c *memorySessionCache) Store(key string, session *bill.Session) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.sessions[key] = *session
+
+	return nil
+}
+
+func (c *memorySessionCache) Delete(key string) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	delete(c.sessions, key)
+
+	return nil
+}
+
+func TestMFAAuthenticateCachesTrustedSession(t *testing.T) {
+	ctx := context.Background()
+	cache := &memorySessionCache{sessions: make(map[string]bill.Session)}
+	server, client := newTestClient(t, bill.WithSessionCache(cache))
+
+	challengeId, err := client.MFAChallenge(ctx, "org-1", false)
+	if err != nil {
+		t.Fatalf("MFAChallenge: %v", err)
+	}
+
+	if _, err := client.MFAAuthenticate(ctx, "org-1", challengeId, "123456", "device-1", "baton-bill"); err != nil {
+		t.Fatalf("MFAAuthenticate: %v", err)
+	}
+
+	fixtures := billtest.DefaultFixtures()
+
+	session, ok, _ := cache.Load(bill.SessionCacheKey(server.BaseURL(), fixtures.Username, fixtures.DeveloperKey, "org-1"))
+	if !ok {
+		t.Fatal("the session wasn't cached")
+	}
+
+	if !session.MFATrusted {
+		t.Error("the cached session isn't MFA-trusted")
+	}
+}
+
+func TestClientReturnsAPIErrors(t *testing.T) {
+	ctx := context.Background()
+	server, client := newTestClient(t)
+
+	server.InjectError("/Crud/Read/User.json", billtest.InjectedError{Code: bill.ErrCodeObjectNotFound, Message: "Object not found."})
+
+	_, err := client.GetUser(ctx, "org-1", "usr-1")
+
+	var apiErr *bill.APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("got error %v, want an APIError", err)
+	}
+
+	if apiErr.Code != bill.ErrCodeObjectNotFound || apiErr.Message != "Object not found." || apiErr.Endpoint != "/Crud/Read/User.json" {
+		t.Errorf("got %+v, want the error of the response envelope", apiErr)
+	}
+}
+
+// sortRecorder records the sort of the list requests it sends, by the file name of their path.
+type sortRecorder struct {
+	mtx   sync.Mutex
+	sorts map[string][]bill.Sort
+}
+
+func (s *sortRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		return nil, err
+	}
+
+	var data struct {
+		Sort []bill.Sort `json:"sort"`
+	}
+	if raw := form.Get("data"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &data); err != nil {
+			return nil, err
+		}
+	}
+
+	s.mtx.Lock()
+	s.sorts[path.Base(req.URL.Path)] = data.Sort
+	s.mtx.Unlock()
+
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestClientSortsListsByUniqueFields(t *testing.T) {
+	ctx := context.Background()
+	fixtures := billtest.DefaultFixtures()
+
+	server := billtest.NewServer(fixtures)
+	t.Cleanup(server.Close)
+
+	recorder := &sortRecorder{sorts: make(map[string][]bill.Sort)}
+	client := bill.NewClient(
+		&http.Client{Transport: recorder},
+		bill.Credentials{
+			Username:     fixtures.Username,
+			Password:     fixtures.Password,
+			DeveloperKey: fixtures.DeveloperKey,
+		},
+		bill.WithBaseURL(server.BaseURL()),
+	)
+
+	if _, err := client.GetUserRoleProfiles(ctx, "org-1", firstPage().PaginationParams); err != nil {
+		t.Fatalf("GetUserRoleProfiles: %v", err)
+	}
+
+	if _, err := client.GetApprovalPolicyApprovers(ctx, "org-1", "apo-1", firstPage().PaginationParams); err != nil {
+		t.Fatalf("GetApprovalPolicyApprovers: %v", err)
+	}
+
+	for file, want := range map[string][]bill.Sort{
+		"Profile.json":                {{Field: "id", Asc: true}},
+		"ApprovalPolicyApprover.json": {{Field: "sortOrder", Asc: true}, {Field: "id", Asc: true}},
+	} {
+		if got := recorder.sorts[file]; !slices.Equal(got, want) {
+			t.Errorf("got %s sorted by %v, want %v", file, got, want)
+		}
+	}
+}
+
+func TestClientKeepsOneSessionPerOrganization(t *testing.T) {
+	ctx := context.Background()
+	server, client := newTestClient(t)
+
+	want := map[string]int{"org-1": 3, "org-2": 1}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 5; i++ {
+		for organizationId, count := range want {
+			wg.Add(1)
+			go func(organizationId string, count int) {
+				defer wg.Done()
+
+				users, err := client.GetUsers(ctx, organizationId, firstPage())
+				if err != nil {
+					errs <- err
+					return
+				}
+
+				if len(users) != count {
+					errs <- fmt.Errorf("got %d users of %s, want %d", len(users), organizationId, count)
+				}
+			}(organizationId, count)
+		}
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+
+	if got := server.Sessions(); got != 2 {
+		t.Errorf("got %d sessions, want one per organization", got)
+	}
+}
+
+func TestClientCloseLogsOutOfEverySession(t *testing.T) {
+	ctx := context.Background()
+	server, client := newTestClient(t)
+
+	for _, organizationId := range []string{"org-1", "org-2"} {
+		if _, err := client.GetUsers(ctx, organizationId, firstPage()); err != nil {
+			t.Fatalf("GetUsers of %s: %v", organizationId, err)
+		}
+	}
+
+	if err := client.Close(ctx); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	if got := server.Sessions(); got != 0 {
+		t.Errorf("got %d open sessions, want none", got)
+	}
+
+	if got := server.Requests("/Logout.json"); got != 2 {
+		t.Errorf("got %d logouts, want 2", got)
+	}
+}
+
+func TestClientCloseKeepsCachedSessions(t *testing.T) {
+	ctx := context.Background()
+	cache := &memorySessionCache{sessions: make(map[string]bill.Session)}
+	server, client := newTestClient(t, bill.WithSessionCache(cache))
+
+	if _, err := client.GetUsers(ctx, "org-1", firstPage()); err != nil {
+		t.Fatalf("GetUsers: %v", err)
+	}
+
+	if err := client.Close(ctx); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	if got := server.Requests("/Logout.json"); got != 0 {
+		t.Errorf("got %d logouts, want the cached session kept for the next run", got)
+	}
+}
@@ -0,0 +1,156 @@
+package bill
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// Entity names used by the generic Crud and List endpoints.
+const (
+	EntityUser                   = "User"
+	EntityProfile                = "Profile"
+	EntityApprovalPolicy         = "ApprovalPolicy"
+	EntityApprovalPolicyApprover = "ApprovalPolicyApprover"
+)
+
+// RequestData is a request option that sets arbitrary fields of the request data.
+type RequestData map[string]interface{}
+
+// Method Apply for RequestData adds every field to the request data.
+func (requestData RequestData) Apply(body *RequestBody) {
+	for key, value := range requestData {
+		body.SetData(key, value)
+	}
+}
+
+func crudPath(operation string, entity string) string {
+	return "/Crud/" + operation + "/" + entity + ".json"
+}
+
+func listPath(entity string) string {
+	return "/List/" + entity + ".json"
+}
+
+// entityObject returns the object in the shape Create and Update expect it, with the entity name set.
+func entityObject(entity string, obj interface{}) (map[string]interface{}, error) {
+	raw, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	rv := make(map[string]interface{})
+	if err := json.Unmarshal(raw, &rv); err != nil {
+		return nil, err
+	}
+
+	rv["entity"] = entity
+
+	return rv, nil
+}
+
+// Read returns the entity with the provided id.
+func Read[T any](ctx context.Context, c *Client, organizationId string, entity string, id string) (T, error) {
+	var response BaseResponse[T]
+
+	err := c.doRequest(
+		ctx,
+		organizationId,
+		crudPath("Read", entity),
+		&response,
+		SearchParams{Id: id},
+	)
+
+	return response.Data, err
+}
+
+// Create creates the entity and returns it as stored by Bill.com.
+func Create[T any](ctx context.Context, c *Client, organizationId string, entity string, obj T) (T, error) {
+	return write[T](ctx, c, organizationId, "Create", entity, obj)
+}
+
+// Update replaces the entity with the object and returns it as stored by Bill.com. The object must hold every
+// required field of the entity, so update the entity as returned by Read or List instead of a partial object.
+func Update[T any](ctx context.Context, c *Client, organizationId string, entity string, obj T) (T, error) {
+	return write[T](ctx, c, organizationId, "Update", entity, obj)
+}
+
+// Delete marks the entity with the provided id as deleted.
+func Delete(ctx context.Context, c *Client, organizationId string, entity string, id string) error {
+	var response BaseResponse[json.RawMessage]
+
+	if c.dryRun {
+		return c.planWrite(ctx, organizationId, crudPath("Delete", entity), SearchParams{Id: id})
+	}
+
+	return c.doRequest(
+		ctx,
+		organizationId,
+		crudPath("Delete", entity),
+		&response,
+		SearchParams{Id: id},
+	)
+}
+
+// Undelete restores the entity with the provided id.
+func Undelete(ctx context.Context, c *Client, organizationId string, entity string, id string) error {
+	var response BaseResponse[json.RawMessage]
+
+	if c.dryRun {
+		return c.planWrite(ctx, organizationId, crudPath("Undelete", entity), SearchParams{Id: id})
+	}
+
+	return c.doRequest(
+		ctx,
+		organizationId,
+		crudPath("Undelete", entity),
+		&response,
+		SearchParams{Id: id},
+	)
+}
+
+// List returns the entities matching the request options, usually a page selected by PaginationParams.
+func List[T any](ctx context.Context, c *Client, organizationId string, entity string, requestOptions ...RequestOption) ([]T, error) {
+	var response BaseResponse[[]T]
+
+	err := c.doRequest(
+		ctx,
+		organizationId,
+		listPath(entity),
+		&response,
+		requestOptions...,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return response.Data, nil
+}
+
+// write sends the object to the Create or Update endpoint of the entity. The object can be of another type than
+// T, e.g. when Create takes fewer fields than the entity has, and the response is decoded as T.
+func write[T any](ctx context.Context, c *Client, organizationId string, operation string, entity string, obj interface{}) (T, error) {
+	var response BaseResponse[T]
+
+	object, err := entityObject(entity, obj)
+	if err != nil {
+		return response.Data, err
+	}
+
+	if c.dryRun {
+		if err := c.planWrite(ctx, organizationId, crudPath(operation, entity), RequestData{"obj": object}); err != nil {
+			return response.Data, err
+		}
+
+		return plannedResult[T](object)
+	}
+
+	err = c.doRequest(
+		ctx,
+		organizationId,
+		crudPath(operation, entity),
+		&response,
+		RequestData{"obj": object},
+	)
+
+	return response.Data, err
+}
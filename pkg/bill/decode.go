@@ -0,0 +1,60 @@
+package bill
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// decodeResponse decodes a Bill.com response in two phases. The envelope is decoded first, with `response_data`
+// left raw, because on error it holds the error details instead of the requested resource. Only successful
+// responses are then decoded into resourceResponse.
+func decodeResponse(endpoint string, statusCode int, body []byte, resourceResponse interface{}) error {
+	var envelope BaseResponse[json.RawMessage]
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		// error pages of proxies and load balancers are not JSON, the status is all there is
+		if statusCode >= http.StatusMultipleChoices {
+			return &APIError{
+				Endpoint:   endpoint,
+				StatusCode: statusCode,
+				Body:       body,
+			}
+		}
+
+		return &DecodeError{
+			Endpoint:   endpoint,
+			StatusCode: statusCode,
+			Body:       body,
+			Err:        err,
+		}
+	}
+
+	if statusCode >= http.StatusMultipleChoices || IsInvalidResponse(envelope) {
+		return decodeError(endpoint, statusCode, body, envelope)
+	}
+
+	if err := json.Unmarshal(body, resourceResponse); err != nil {
+		return &DecodeError{
+			Endpoint:   endpoint,
+			StatusCode: statusCode,
+			Body:       body,
+			Err:        err,
+		}
+	}
+
+	return nil
+}
+
+// decodeError returns the APIError described by the `response_data` of a failed request.
+func decodeError(endpoint string, statusCode int, body []byte, envelope BaseResponse[json.RawMessage]) *APIError {
+	var errorData ErrorData
+	if err := json.Unmarshal(envelope.Data, &errorData); err != nil || errorData.Code == "" {
+		return &APIError{
+			Message:    envelope.Message,
+			Endpoint:   endpoint,
+			StatusCode: statusCode,
+			Body:       body,
+		}
+	}
+
+	return newAPIError(endpoint, statusCode, errorData)
+}
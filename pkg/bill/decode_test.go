@@ -0,0 +1,100 @@
+package bill_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ConductorOne/baton-bill/pkg/bill"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestClientDecodesResponses(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		body       string
+		wantCode   codes.Code
+		check      func(t *testing.T, err error)
+	}{
+		{
+			name:       "error envelope",
+			statusCode: http.StatusOK,
+			body:       `{"response_status":1,"response_message":"Error","response_data":{"error_code":"BDC_1102","error_message":"No permission."}}`,
+			wantCode:   codes.PermissionDenied,
+			check: func(t *testing.T, err error) {
+				var apiErr *bill.APIError
+				if !errors.As(err, &apiErr) || apiErr.Code != bill.ErrCodeNoPermission || apiErr.Message != "No permission." {
+					t.Errorf("got %v, want the APIError of the envelope", err)
+				}
+			},
+		},
+		{
+			name:       "error envelope without details",
+			statusCode: http.StatusOK,
+			body:       `{"response_status":1,"response_message":"Error","response_data":"oops"}`,
+			wantCode:   codes.Unknown,
+			check: func(t *testing.T, err error) {
+				var apiErr *bill.APIError
+				if !errors.As(err, &apiErr) || apiErr.Message != "Error" || len(apiErr.Body) == 0 {
+					t.Errorf("got %v, want an APIError keeping the body", err)
+				}
+			},
+		},
+		{
+			name:       "proxy error page",
+			statusCode: http.StatusServiceUnavailable,
+			body:       `<html>Service Unavailable</html>`,
+			wantCode:   codes.Unavailable,
+			check: func(t *testing.T, err error) {
+				var apiErr *bill.APIError
+				if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable {
+					t.Errorf("got %v, want an APIError with the status", err)
+				}
+			},
+		},
+		{
+			name:       "unexpected data",
+			statusCode: http.StatusOK,
+			body:       `{"response_status":0,"response_message":"Success","response_data":{"id":"org-1"}}`,
+			wantCode:   codes.Internal,
+			check: func(t *testing.T, err error) {
+				var decodeErr *bill.DecodeError
+				if !errors.As(err, &decodeErr) || string(decodeErr.Body) == "" || decodeErr.Endpoint != "/ListOrgs.json" {
+					t.Errorf("got %v, want a DecodeError keeping the body", err)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.statusCode)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			client := bill.NewClient(
+				server.Client(),
+				bill.Credentials{Username: "user", Password: "password", DeveloperKey: "dev-key"},
+				bill.WithBaseURL(server.URL),
+				bill.WithRateLimit(bill.RateLimitConfig{MaxRetries: 0, BaseBackoff: 1, MaxBackoff: 1}),
+			)
+
+			_, err := client.GetOrganizations(context.Background())
+			if err == nil {
+				t.Fatal("got no error")
+			}
+
+			if got := status.Code(err); got != tt.wantCode {
+				t.Errorf("got code %s, want %s", got, tt.wantCode)
+			}
+
+			tt.check(t, err)
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package bill
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+)
+
+// WithDryRun makes the client log the Create, Update, Delete and Undelete requests instead of
+// sending them. Reads are still sent, so the planned writes are based on the current state.
+func WithDryRun(dryRun bool) ClientOption {
+	return func(c *Client) {
+		c.dryRun = dryRun
+	}
+}
+
+// WithRedactor sets the redactor masking the planned writes of dry-run mode. By default only the secrets are
+// masked, so the planned writes show who they would create or change.
+func WithRedactor(redactor *Redactor) ClientOption {
+	return func(c *Client) {
+		c.redactor = redactor
+	}
+}
+
+// DryRun reports whether the client runs in dry-run mode.
+func (c *Client) DryRun() bool {
+	return c.dryRun
+}
+
+// planWrite logs the write request, with the secrets redacted, instead of sending it. The session of the organization is still resolved,
+// so a dry run fails when the write would fail to log in.
+func (c *Client) planWrite(ctx context.Context, organizationId string, path string, requestOptions ...RequestOption) error {
+	session, err := c.session(ctx, organizationId)
+	if err != nil {
+		return err
+	}
+
+	requestBody := newRequestBody()
+	for _, option := range c.withSession(session, requestOptions) {
+		if option != nil {
+			option.Apply(requestBody)
+		}
+	}
+
+	encodedBody, err := requestBody.Encode()
+	if err != nil {
+		return err
+	}
+
+	form, err := url.ParseQuery(encodedBody)
+	if err != nil {
+		return err
+	}
+
+	ctxzap.Extract(ctx).Info(
+		"bill: dry run, not sending the request",
+		zap.String("organization_id", organizationId),
+		zap.String("endpoint", path),
+		zap.Any("form", c.redactor.Form(path, form)),
+	)
+
+	return nil
+}
+
+// plannedResult returns the object a dry-run write would have sent, decoded as the response of the write.
+func plannedResult[T any](obj interface{}) (T, error) {
+	var rv T
+
+	raw, err := json.Marshal(obj)
+	if err != nil {
+		return rv, err
+	}
+
+	err = json.Unmarshal(raw, &rv)
+
+	return rv, err
+}
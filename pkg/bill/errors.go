@@ -0,0 +1,130 @@
+package bill
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Error codes returned by the Bill.com API in the `error_code` field (Read more at https://developer.bill.com/docs/error-codes)
+const (
+	ErrCodeInvalidSession  = "BDC_1109"
+	ErrCodeNoPermission    = "BDC_1102"
+	ErrCodeObjectNotFound  = "BDC_1153"
+	ErrCodeTooManyRequests = "BDC_1144"
+)
+
+var errorCodes = map[string]codes.Code{
+	ErrCodeInvalidSession:  codes.Unauthenticated,
+	ErrCodeNoPermission:    codes.PermissionDenied,
+	ErrCodeObjectNotFound:  codes.NotFound,
+	ErrCodeTooManyRequests: codes.ResourceExhausted,
+}
+
+// ErrorData is the shape of `response_data` when the Bill.com API returns an error.
+type ErrorData struct {
+	Code    string `json:"error_code"`
+	Message string `json:"error_message"`
+}
+
+// APIError is returned when a request to the Bill.com API fails.
+type APIError struct {
+	Code       string
+	Message    string
+	Endpoint   string
+	StatusCode int
+	// Body holds the raw response when the error details couldn't be decoded from it.
+	Body []byte
+}
+
+func newAPIError(endpoint string, statusCode int, data ErrorData) *APIError {
+	return &APIError{
+		Code:       data.Code,
+		Message:    data.Message,
+		Endpoint:   endpoint,
+		StatusCode: statusCode,
+	}
+}
+
+func (e *APIError) Error() string {
+	if e.Code == "" && e.Message != "" {
+		return fmt.Sprintf("bill: request to %s failed with status %d: %s", e.Endpoint, e.StatusCode, e.Message)
+	}
+
+	if e.Code == "" {
+		return fmt.Sprintf("bill: request to %s failed with status %d", e.Endpoint, e.StatusCode)
+	}
+
+	return fmt.Sprintf("bill: request to %s failed: %s: %s", e.Endpoint, e.Code, e.Message)
+}
+
+// GRPCCode maps the Bill.com error code, or the HTTP status if there is none, to a gRPC code.
+func (e *APIError) GRPCCode() codes.Code {
+	if code, ok := errorCodes[e.Code]; ok {
+		return code
+	}
+
+	switch e.StatusCode {
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	}
+
+	return codes.Unknown
+}
+
+// GRPCStatus allows the error to be converted with status.FromError and status.Code.
+func (e *APIError) GRPCStatus() *status.Status {
+	return status.New(e.GRPCCode(), e.Error())
+}
+
+// DecodeError is returned when a successful Bill.com response doesn't have the expected shape.
+// It keeps the raw body, so the response can be diagnosed.
+type DecodeError struct {
+	Endpoint   string
+	StatusCode int
+	Body       []byte
+	Err        error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("bill: failed to decode the response of %s: %v", e.Endpoint, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// GRPCCode returns Unavailable for server errors, which are usually temporary, and Internal otherwise.
+func (e *DecodeError) GRPCCode() codes.Code {
+	if e.StatusCode >= http.StatusInternalServerError {
+		return codes.Unavailable
+	}
+
+	return codes.Internal
+}
+
+// GRPCStatus allows the error to be converted with status.FromError and status.Code.
+func (e *DecodeError) GRPCStatus() *status.Status {
+	return status.New(e.GRPCCode(), e.Error())
+}
+
+// IsInvalidSessionError reports whether the error means the session is invalid or has expired.
+func IsInvalidSessionError(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+
+	return apiErr.Code == ErrCodeInvalidSession
+}
@@ -0,0 +1,81 @@
+package bill
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+)
+
+// LoggingTransport logs the Bill.com requests and responses at debug level, with the bodies masked by a Redactor.
+// Bodies are only read when debug logging is enabled.
+type LoggingTransport struct {
+	next     http.RoundTripper
+	logger   *zap.Logger
+	redactor *Redactor
+}
+
+// NewLoggingTransport returns a transport logging the exchanges of next. When logger is nil, the logger of the
+// request context is used.
+func NewLoggingTransport(next http.RoundTripper, logger *zap.Logger, redactor *Redactor) *LoggingTransport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	return &LoggingTransport{
+		next:     next,
+		logger:   logger,
+		redactor: redactor,
+	}
+}
+
+func (t *LoggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	l := t.logger
+	if l == nil {
+		l = ctxzap.Extract(req.Context())
+	}
+
+	if !l.Core().Enabled(zap.DebugLevel) {
+		return t.next.RoundTrip(req)
+	}
+
+	fields := []zap.Field{
+		zap.String("http.method", req.Method),
+		zap.String("http.url_details.host", req.URL.Host),
+		zap.String("http.url_details.path", req.URL.Path),
+	}
+
+	form, err := readForm(req)
+	if err != nil {
+		return nil, err
+	}
+
+	l.Debug("Request started", append(fields, zap.Any("http.request.form", t.redactor.Form(req.URL.Path, form)))...)
+
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	fields = append(fields, zap.Duration("http.duration", time.Since(start)))
+
+	if err != nil {
+		l.Debug("Request complete", append(fields, zap.Error(err))...)
+		return nil, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	l.Debug("Request complete", append(fields,
+		zap.Int("http.status_code", resp.StatusCode),
+		zap.ByteString("http.response.body", t.redactor.JSON(req.URL.Path, body)),
+	)...)
+
+	return resp, nil
+}
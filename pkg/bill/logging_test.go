@@ -0,0 +1,91 @@
+package bill_test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ConductorOne/baton-bill/pkg/bill"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+const userResponse = `{"response_status":0,"response_message":"Success","response_data":[{"id":"usr-1","name":"Ada Admin","email":"ada@example.com"}]}`
+
+// newUserServer returns a server answering every request with the users of userResponse.
+func newUserServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, userResponse)
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+// postUsersByEmail sends the request listing the users with the email, like GetUsersByEmail, and returns the body
+// of the response.
+func postUsersByEmail(t *testing.T, client *http.Client, baseURL string) string {
+	t.Helper()
+
+	form := loginForm()
+	form.Set("sessionId", "session-1")
+	form.Set("data", `{"start":0,"max":999,"filters":[{"field":"email","op":"=","value":"ada@example.com"}]}`)
+
+	resp, err := client.PostForm(baseURL+listUsersPath, form)
+	if err != nil {
+		t.Fatalf("PostForm: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+
+	return string(body)
+}
+
+func TestLoggingTransportMasksLoggedBodies(t *testing.T) {
+	server := newUserServer(t)
+
+	core, logs := observer.New(zapcore.DebugLevel)
+	client := &http.Client{Transport: bill.NewLoggingTransport(nil, zap.New(core), nil)}
+
+	if got := postUsersByEmail(t, client, server.URL); got != userResponse {
+		t.Errorf("got response %s, want the response unchanged", got)
+	}
+
+	if logs.Len() != 2 {
+		t.Fatalf("got %d log entries, want 2", logs.Len())
+	}
+
+	for _, entry := range logs.All() {
+		logged := fmt.Sprint(entry.ContextMap())
+
+		for _, secret := range []string{"integration@example.com", "hunter2", "dev-key", "session-1", "ada@example.com", "Ada Admin"} {
+			if strings.Contains(logged, secret) {
+				t.Errorf("got %q logged in %s", secret, logged)
+			}
+		}
+	}
+}
+
+func TestLoggingTransportSkipsBodiesWithoutDebugLogging(t *testing.T) {
+	server := newUserServer(t)
+
+	core, logs := observer.New(zapcore.InfoLevel)
+	client := &http.Client{Transport: bill.NewLoggingTransport(nil, zap.New(core), nil)}
+
+	postUsersByEmail(t, client, server.URL)
+
+	if logs.Len() != 0 {
+		t.Errorf("got %d log entries, want 0", logs.Len())
+	}
+}
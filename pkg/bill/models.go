@@ -1,14 +1,36 @@
 package bill
 
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
 type BaseResource struct {
 	Id string `json:"id"`
 }
 
+func (r BaseResource) GetId() string {
+	return r.Id
+}
+
 type LoginData struct {
 	SessionId string `json:"sessionId"`
 	OrgId     string `json:"orgId"`
 }
 
+type MFAChallengeData struct {
+	ChallengeId string `json:"challengeId"`
+}
+
+type MFAAuthenticateData struct {
+	MFAId string `json:"mfaId"`
+}
+
+type MFAStatusData struct {
+	IsTrusted bool `json:"isTrusted"`
+}
+
 type SessionDetails struct {
 	OrgId  string `json:"organizationId"`
 	UserId string `json:"userId"`
@@ -16,14 +38,84 @@ type SessionDetails struct {
 
 type User struct {
 	BaseResource
+	FirstName       string     `json:"firstName"`
+	LastName        string     `json:"lastName"`
+	Email           string     `json:"email"`
+	Name            string     `json:"name,omitempty"`
+	IsActive        ActiveFlag `json:"isActive"`
+	RoleId          string     `json:"profileId"`
+	TimezoneId      string     `json:"timezoneId,omitempty"`
+	PartnerUserGuid string     `json:"partnerUserGuid,omitempty"`
+	CreatedTime     string     `json:"createdTime,omitempty"`
+	UpdatedTime     string     `json:"updatedTime,omitempty"`
+}
+
+// UserCreate holds the fields of a user created by CreateUser.
+type UserCreate struct {
 	FirstName string `json:"firstName"`
 	LastName  string `json:"lastName"`
 	Email     string `json:"email"`
-	Name      string `json:"name"`
-	IsActive  bool   `json:"isActive"`
 	RoleId    string `json:"profileId"`
 }
 
+// DisplayName returns the name of the user, falling back to the email when the user has no name.
+func (u User) DisplayName() string {
+	if u.Name != "" {
+		return u.Name
+	}
+
+	name := strings.TrimSpace(u.FirstName + " " + u.LastName)
+	if name != "" {
+		return name
+	}
+
+	return u.Email
+}
+
+// ActiveFlag is the `isActive` field of Bill.com entities. The API sends "1" for active and "2" for inactive
+// entities, older responses and the fake server use JSON booleans.
+type ActiveFlag bool
+
+const (
+	activeFlagActive   = "1"
+	activeFlagInactive = "2"
+)
+
+func (f ActiveFlag) MarshalJSON() ([]byte, error) {
+	if f {
+		return json.Marshal(activeFlagActive)
+	}
+
+	return json.Marshal(activeFlagInactive)
+}
+
+func (f *ActiveFlag) UnmarshalJSON(data []byte) error {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	switch v := value.(type) {
+	case bool:
+		*f = ActiveFlag(v)
+	case string:
+		switch v {
+		case activeFlagActive, "true":
+			*f = true
+		case activeFlagInactive, "false", "":
+			*f = false
+		default:
+			return fmt.Errorf("bill: invalid isActive value %q", v)
+		}
+	case nil:
+		*f = false
+	default:
+		return fmt.Errorf("bill: invalid isActive value %s", data)
+	}
+
+	return nil
+}
+
 type Organization struct {
 	Id   string `json:"orgId"`
 	Name string `json:"orgName"`
@@ -36,6 +128,26 @@ type UserRoleProfile struct {
 	Description string `json:"description"`
 }
 
+// ApprovalPolicy routes the approval of bills, vendor credits and payments to approvers.
+// The amounts are the bounds of the policy, e.g. bills over 1000.
+type ApprovalPolicy struct {
+	BaseResource
+	Name       string      `json:"name"`
+	IsActive   ActiveFlag  `json:"isActive"`
+	ObjectType string      `json:"objectType"`
+	MinAmount  json.Number `json:"minAmount,omitempty"`
+	MaxAmount  json.Number `json:"maxAmount,omitempty"`
+}
+
+// ApprovalPolicyApprover is a user who approves in an approval policy. Approvers approve in ascending SortOrder.
+type ApprovalPolicyApprover struct {
+	BaseResource
+	IsActive         ActiveFlag  `json:"isActive"`
+	ApprovalPolicyId string      `json:"approvalPolicyId"`
+	UserId           string      `json:"usersId"`
+	SortOrder        json.Number `json:"sortOrder,omitempty"`
+}
+
 type BaseResponse[T any] struct {
 	Status  int    `json:"response_status"`
 	Message string `json:"response_message"`
@@ -0,0 +1,138 @@
+package bill
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+)
+
+// Identifiable is implemented by Bill.com entities that have an id.
+type Identifiable interface {
+	GetId() string
+}
+
+// ListFunc fetches a single page of a Bill.com list endpoint.
+type ListFunc[T any] func(ctx context.Context, params PaginationParams) ([]T, error)
+
+// pageTokenSeparator separates the offset of the next page from the id of the last entity returned in page tokens.
+const pageTokenSeparator = ":"
+
+// Paginator iterates over the pages of a Bill.com list endpoint.
+// It stops on the first page that is shorter than the page size and skips entities it has already returned.
+//
+// Pages are fetched by offset, so entities added to the list between two pages shift the entities after them
+// to the next page. The id of the last entity returned is kept in the page token, and the entities up to it
+// are dropped from the next page, even when it is fetched by another paginator resuming from the token.
+type Paginator[T Identifiable] struct {
+	bag      *pagination.Bag
+	pageSize int
+	list     ListFunc[T]
+	start    int
+	lastId   string
+	done     bool
+	seen     map[string]struct{}
+}
+
+// Paginate returns a paginator for the list endpoint that starts at the page token stored in the bag.
+func Paginate[T Identifiable](bag *pagination.Bag, pageSize int, list ListFunc[T]) (*Paginator[T], error) {
+	start := 0
+	lastId := ""
+
+	if pageToken := bag.PageToken(); pageToken != "" {
+		var err error
+		var offset string
+
+		offset, lastId, _ = strings.Cut(pageToken, pageTokenSeparator)
+
+		start, err = strconv.Atoi(offset)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &Paginator[T]{
+		bag:      bag,
+		pageSize: pageSize,
+		list:     list,
+		start:    start,
+		lastId:   lastId,
+		seen:     make(map[string]struct{}),
+	}, nil
+}
+
+// HasNext reports whether there may be more entities to fetch.
+func (p *Paginator[T]) HasNext() bool {
+	return !p.done
+}
+
+// Next fetches the next page of entities.
+func (p *Paginator[T]) Next(ctx context.Context) ([]T, error) {
+	if p.done {
+		return nil, nil
+	}
+
+	page, err := p.list(ctx, PaginationParams{
+		Start: p.start,
+		Max:   p.pageSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	p.start += len(page)
+	if len(page) < p.pageSize {
+		p.done = true
+	}
+
+	// the entities up to the last one returned were shifted from the previous page
+	for i, entity := range page {
+		if p.lastId != "" && entity.GetId() == p.lastId {
+			page = page[i+1:]
+			break
+		}
+	}
+
+	rv := make([]T, 0, len(page))
+	for _, entity := range page {
+		if _, ok := p.seen[entity.GetId()]; ok {
+			continue
+		}
+
+		p.seen[entity.GetId()] = struct{}{}
+		rv = append(rv, entity)
+	}
+
+	if len(rv) > 0 {
+		p.lastId = rv[len(rv)-1].GetId()
+	}
+
+	return rv, nil
+}
+
+// All fetches all the remaining pages of entities.
+func (p *Paginator[T]) All(ctx context.Context) ([]T, error) {
+	var rv []T
+
+	for p.HasNext() {
+		page, err := p.Next(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		rv = append(rv, page...)
+	}
+
+	return rv, nil
+}
+
+// NextToken returns the page token that resumes the listing after the fetched pages,
+// or an empty token once the last page was fetched.
+func (p *Paginator[T]) NextToken() (string, error) {
+	if p.done {
+		return p.bag.NextToken("")
+	}
+
+	return p.bag.NextToken(strconv.Itoa(p.start) + pageTokenSeparator + p.lastId)
+}
@@ -0,0 +1,135 @@
+package bill_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/ConductorOne/baton-bill/pkg/bill"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+)
+
+type entity struct {
+	id string
+}
+
+func (e entity) GetId() string {
+	return e.id
+}
+
+// listOf returns a list endpoint over the entities, which can change between pages.
+func listOf(entities *[]entity) bill.ListFunc[entity] {
+	return func(ctx context.Context, params bill.PaginationParams) ([]entity, error) {
+		start := params.Start
+		if start > len(*entities) {
+			start = len(*entities)
+		}
+
+		end := start + params.Max
+		if end > len(*entities) {
+			end = len(*entities)
+		}
+
+		return append([]entity(nil), (*entities)[start:end]...), nil
+	}
+}
+
+// nextPage fetches the page of the token with a new paginator, like a syncer does on every call.
+func nextPage(t *testing.T, token string, list bill.ListFunc[entity]) ([]string, string) {
+	t.Helper()
+
+	bag := &pagination.Bag{}
+	if err := bag.Unmarshal(token); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+
+	if bag.Current() == nil {
+		bag.Push(pagination.PageState{ResourceTypeID: "user"})
+	}
+
+	paginator, err := bill.Paginate(bag, 2, list)
+	if err != nil {
+		t.Fatalf("Paginate: %v", err)
+	}
+
+	page, err := paginator.Next(context.Background())
+	if err != nil {
+		t.Fatalf("Next: %v", err)
+	}
+
+	nextToken, err := paginator.NextToken()
+	if err != nil {
+		t.Fatalf("NextToken: %v", err)
+	}
+
+	ids := make([]string, 0, len(page))
+	for _, e := range page {
+		ids = append(ids, e.id)
+	}
+
+	return ids, nextToken
+}
+
+func TestPaginatorEndsOnShortPage(t *testing.T) {
+	tests := []struct {
+		name      string
+		entities  []entity
+		wantPages int
+	}{
+		{name: "short last page", entities: []entity{{"a"}, {"b"}, {"c"}}, wantPages: 2},
+		{name: "full last page", entities: []entity{{"a"}, {"b"}, {"c"}, {"d"}}, wantPages: 3},
+		{name: "empty", entities: nil, wantPages: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list := listOf(&tt.entities)
+
+			var ids []string
+			token := ""
+			for pages := 1; ; pages++ {
+				var page []string
+				page, token = nextPage(t, token, list)
+				ids = append(ids, page...)
+
+				if token == "" {
+					if pages != tt.wantPages {
+						t.Errorf("got %d pages, want %d", pages, tt.wantPages)
+					}
+					break
+				}
+
+				if pages > tt.wantPages {
+					t.Fatalf("no empty token after %d pages", pages)
+				}
+			}
+
+			if len(ids) != len(tt.entities) {
+				t.Errorf("got %v, want every entity once", ids)
+			}
+		})
+	}
+}
+
+func TestPaginatorSkipsEntitiesShiftedToTheNextPage(t *testing.T) {
+	entities := []entity{{"b"}, {"c"}, {"d"}, {"e"}}
+	list := listOf(&entities)
+
+	page, token := nextPage(t, "", list)
+	if !reflect.DeepEqual(page, []string{"b", "c"}) {
+		t.Fatalf("got first page %v", page)
+	}
+
+	// an entity added before the end of the first page shifts c to the second page
+	entities = append([]entity{{"a"}}, entities...)
+
+	var ids []string
+	for token != "" {
+		page, token = nextPage(t, token, list)
+		ids = append(ids, page...)
+	}
+
+	if !reflect.DeepEqual(ids, []string{"d", "e"}) {
+		t.Errorf("got %v after the first page, want [d e]", ids)
+	}
+}
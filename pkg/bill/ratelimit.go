@@ -0,0 +1,222 @@
+package bill
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Bill.com limits the number of concurrent requests and the number of requests per hour for each developer key.
+const (
+	DefaultMaxConcurrentRequests = 3
+	DefaultRequestsPerPeriod     = 20000
+	DefaultRateLimitPeriod       = time.Hour
+	DefaultMaxRetries            = 5
+	DefaultBaseBackoff           = time.Second
+	DefaultMaxBackoff            = time.Minute
+)
+
+// RateLimitConfig configures how the client throttles requests to the Bill.com API.
+type RateLimitConfig struct {
+	MaxConcurrentRequests int
+	RequestsPerPeriod     int
+	Period                time.Duration
+	MaxRetries            int
+	BaseBackoff           time.Duration
+	MaxBackoff            time.Duration
+}
+
+// RateLimitStatus describes the state of the request budget of the client.
+type RateLimitStatus struct {
+	Limit     int64
+	Remaining int64
+	ResetAt   time.Time
+	Limited   bool
+}
+
+func DefaultRateLimitConfig() RateLimitConfig {
+	return RateLimitConfig{
+		MaxConcurrentRequests: DefaultMaxConcurrentRequests,
+		RequestsPerPeriod:     DefaultRequestsPerPeriod,
+		Period:                DefaultRateLimitPeriod,
+		MaxRetries:            DefaultMaxRetries,
+		BaseBackoff:           DefaultBaseBackoff,
+		MaxBackoff:            DefaultMaxBackoff,
+	}
+}
+
+// withDefaults fills unset values of the config with the defaults.
+func (cfg RateLimitConfig) withDefaults() RateLimitConfig {
+	defaults := DefaultRateLimitConfig()
+
+	if cfg.MaxConcurrentRequests <= 0 {
+		cfg.MaxConcurrentRequests = defaults.MaxConcurrentRequests
+	}
+
+	if cfg.RequestsPerPeriod <= 0 {
+		cfg.RequestsPerPeriod = defaults.RequestsPerPeriod
+	}
+
+	if cfg.Period <= 0 {
+		cfg.Period = defaults.Period
+	}
+
+	if cfg.MaxRetries < 0 {
+		cfg.MaxRetries = defaults.MaxRetries
+	}
+
+	if cfg.BaseBackoff <= 0 {
+		cfg.BaseBackoff = defaults.BaseBackoff
+	}
+
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = defaults.MaxBackoff
+	}
+
+	return cfg
+}
+
+// rateLimiter bounds the number of requests in flight and the number of requests sent in each period.
+type rateLimiter struct {
+	config       RateLimitConfig
+	slots        chan struct{}
+	mtx          sync.Mutex
+	periodStart  time.Time
+	count        int
+	limitedUntil time.Time
+}
+
+func newRateLimiter(config RateLimitConfig) *rateLimiter {
+	config = config.withDefaults()
+
+	return &rateLimiter{
+		config: config,
+		slots:  make(chan struct{}, config.MaxConcurrentRequests),
+	}
+}
+
+// acquire blocks until a request can be sent and returns the function that releases the concurrency slot.
+func (l *rateLimiter) acquire(ctx context.Context) (func(), error) {
+	select {
+	case l.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	release := func() {
+		<-l.slots
+	}
+
+	for {
+		wait := l.reserve()
+		if wait == 0 {
+			return release, nil
+		}
+
+		err := sleep(ctx, wait)
+		if err != nil {
+			release()
+			return nil, err
+		}
+	}
+}
+
+// reserve counts the request against the current period, or returns how long to wait for the next one.
+func (l *rateLimiter) reserve() time.Duration {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	now := time.Now()
+	if now.Before(l.limitedUntil) {
+		return l.limitedUntil.Sub(now)
+	}
+
+	if now.Sub(l.periodStart) >= l.config.Period {
+		l.periodStart = now
+		l.count = 0
+	}
+
+	if l.count >= l.config.RequestsPerPeriod {
+		return l.periodStart.Add(l.config.Period).Sub(now)
+	}
+
+	l.count++
+
+	return 0
+}
+
+// backoff returns the jittered exponential delay before the given retry attempt and holds back
+// other requests until it passes.
+func (l *rateLimiter) backoff(attempt int) time.Duration {
+	delay := l.config.BaseBackoff << attempt
+	if delay <= 0 || delay > l.config.MaxBackoff {
+		delay = l.config.MaxBackoff
+	}
+
+	// pick a delay between half and the full backoff so retrying clients don't synchronize
+	half := int64(delay / 2)
+	delay = time.Duration(half + rand.Int63n(half+1)) //nolint:gosec // jitter doesn't need a secure random source
+
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	limitedUntil := time.Now().Add(delay)
+	if limitedUntil.After(l.limitedUntil) {
+		l.limitedUntil = limitedUntil
+	}
+
+	return delay
+}
+
+func (l *rateLimiter) status() RateLimitStatus {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	now := time.Now()
+	resetAt := l.periodStart.Add(l.config.Period)
+	remaining := l.config.RequestsPerPeriod - l.count
+
+	if now.After(resetAt) {
+		resetAt = now.Add(l.config.Period)
+		remaining = l.config.RequestsPerPeriod
+	}
+
+	limited := now.Before(l.limitedUntil) || remaining <= 0
+	if now.Before(l.limitedUntil) {
+		resetAt = l.limitedUntil
+	}
+
+	return RateLimitStatus{
+		Limit:     int64(l.config.RequestsPerPeriod),
+		Remaining: int64(remaining),
+		ResetAt:   resetAt,
+		Limited:   limited,
+	}
+}
+
+// IsRateLimitError reports whether the request was rejected because of Bill.com rate limits.
+func IsRateLimitError(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+
+	return apiErr.Code == ErrCodeTooManyRequests ||
+		apiErr.StatusCode == http.StatusTooManyRequests ||
+		apiErr.StatusCode == http.StatusServiceUnavailable
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,246 @@
+package bill
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Recording is a request/response pair saved by RecordingTransport, with the secrets redacted.
+type Recording struct {
+	Method      string          `json:"method"`
+	Path        string          `json:"path"`
+	Form        url.Values      `json:"form"`
+	StatusCode  int             `json:"statusCode"`
+	ContentType string          `json:"contentType,omitempty"`
+	Body        json.RawMessage `json:"body,omitempty"`
+	// RawBody holds response bodies that are not JSON, like the error pages of proxies.
+	RawBody string `json:"rawBody,omitempty"`
+}
+
+// RecordingTransport saves every request sent to Bill.com and its response as a fixture file in a directory.
+// Credentials, session ids, the developer key and the PII fields of the redactor are removed before anything is written.
+type RecordingTransport struct {
+	next     http.RoundTripper
+	dir      string
+	redactor *Redactor
+	mtx      sync.Mutex
+	seq      int
+}
+
+// NewRecordingTransport returns a transport recording the exchanges of next into dir.
+func NewRecordingTransport(next http.RoundTripper, dir string, redactor *Redactor) (*RecordingTransport, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("bill: failed to create the record directory %s: %w", dir, err)
+	}
+
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	return &RecordingTransport{
+		next:     next,
+		dir:      dir,
+		redactor: redactor,
+	}, nil
+}
+
+func (t *RecordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	form, err := readForm(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	recording := Recording{
+		Method:      req.Method,
+		Path:        req.URL.Path,
+		Form:        t.redactor.Form(req.URL.Path, form),
+		StatusCode:  resp.StatusCode,
+		ContentType: resp.Header.Get("Content-Type"),
+	}
+
+	if json.Valid(body) {
+		recording.Body = t.redactor.JSON(req.URL.Path, body)
+	} else {
+		recording.RawBody = string(body)
+	}
+
+	if err := t.save(recording); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+func (t *RecordingTransport) save(recording Recording) error {
+	data, err := json.MarshalIndent(recording, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	t.seq++
+	name := fmt.Sprintf("%05d-%s.json", t.seq, recordingName(recording.Path))
+
+	return os.WriteFile(filepath.Join(t.dir, name), data, 0o600)
+}
+
+// ReplayTransport serves the fixtures saved by RecordingTransport instead of calling Bill.com.
+// A request is answered by the first unused recording with the same path and (redacted) form, in the order
+// they were recorded. Once all of them are used, the last one is served again, so retried requests still
+// get an answer. The redactor must mask the same fields as the one used for the recording.
+type ReplayTransport struct {
+	mtx        sync.Mutex
+	recordings []Recording
+	used       []bool
+	redactor   *Redactor
+}
+
+// NewReplayTransport loads the recordings saved in dir.
+func NewReplayTransport(dir string, redactor *Redactor) (*ReplayTransport, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("bill: no recordings found in %s", dir)
+	}
+
+	// the file names start with the sequence number, so they sort in the order they were recorded
+	sort.Strings(paths)
+
+	recordings := make([]Recording, 0, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		var recording Recording
+		if err := json.Unmarshal(data, &recording); err != nil {
+			return nil, fmt.Errorf("bill: invalid recording %s: %w", path, err)
+		}
+
+		recordings = append(recordings, recording)
+	}
+
+	return &ReplayTransport{
+		recordings: recordings,
+		used:       make([]bool, len(recordings)),
+		redactor:   redactor,
+	}, nil
+}
+
+func (t *ReplayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	form, err := readForm(req)
+	if err != nil {
+		return nil, err
+	}
+
+	recording, ok := t.match(req.Method, req.URL.Path, t.redactor.Form(req.URL.Path, form).Encode())
+	if !ok {
+		return nil, fmt.Errorf("bill: no recording matches %s %s", req.Method, req.URL.Path)
+	}
+
+	body := []byte(recording.Body)
+	if len(body) == 0 {
+		body = []byte(recording.RawBody)
+	}
+
+	header := http.Header{}
+	if recording.ContentType != "" {
+		header.Set("Content-Type", recording.ContentType)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", recording.StatusCode, http.StatusText(recording.StatusCode)),
+		StatusCode:    recording.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+func (t *ReplayTransport) match(method string, path string, form string) (Recording, bool) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	last := -1
+	for i, recording := range t.recordings {
+		if recording.Method != method || recording.Path != path || recording.Form.Encode() != form {
+			continue
+		}
+
+		if !t.used[i] {
+			t.used[i] = true
+			return recording, true
+		}
+
+		last = i
+	}
+
+	if last == -1 {
+		return Recording{}, false
+	}
+
+	return t.recordings[last], true
+}
+
+// readForm returns the form fields of the request body, leaving the body readable for the next transport.
+func readForm(req *http.Request) (url.Values, error) {
+	if req.Body == nil {
+		return url.Values{}, nil
+	}
+
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	req.Body = io.NopCloser(bytes.NewReader(body))
+
+	return url.ParseQuery(string(body))
+}
+
+// recordingName turns the request path into a file name, e.g. /api/v2/List/User.json becomes List-User.
+func recordingName(path string) string {
+	name := strings.TrimSuffix(path, ".json")
+	name = strings.TrimPrefix(name, "/api/v2")
+	name = strings.Trim(name, "/")
+	name = strings.ReplaceAll(name, "/", "-")
+
+	if name == "" {
+		return "request"
+	}
+
+	return name
+}
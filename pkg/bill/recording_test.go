@@ -0,0 +1,49 @@
+package bill_test
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ConductorOne/baton-bill/pkg/bill"
+)
+
+func TestRecordingTransportMasksRecordings(t *testing.T) {
+	server := newUserServer(t)
+	dir := t.TempDir()
+
+	transport, err := bill.NewRecordingTransport(nil, dir, nil)
+	if err != nil {
+		t.Fatalf("NewRecordingTransport: %v", err)
+	}
+
+	if got := postUsersByEmail(t, &http.Client{Transport: transport}, server.URL); got != userResponse {
+		t.Errorf("got response %s, want the response unchanged", got)
+	}
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		t.Fatalf("Glob: %v", err)
+	}
+
+	if len(paths) != 1 || filepath.Base(paths[0]) != "00001-List-User.json" {
+		t.Fatalf("got recordings %v, want 00001-List-User.json", paths)
+	}
+
+	data, err := os.ReadFile(paths[0])
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+
+	for _, secret := range []string{"integration@example.com", "hunter2", "dev-key", "session-1", "ada@example.com", "Ada Admin"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("got %q recorded in %s", secret, data)
+		}
+	}
+
+	if !strings.Contains(string(data), "usr-1") {
+		t.Errorf("got recording %s, want the user id kept", data)
+	}
+}
@@ -0,0 +1,164 @@
+package bill
+
+import (
+	"encoding/json"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// Redacted replaces the values of secret fields in recordings and logs.
+const Redacted = "REDACTED"
+
+// secretFields are the request and response fields holding credentials or session tokens. They are always redacted.
+var secretFields = []string{
+	"userName",
+	"password",
+	"devKey",
+	"sessionId",
+	"mfaId",
+	"deviceId",
+}
+
+// DefaultRedactFields are the PII fields of Bill.com users masked unless other fields are configured.
+var DefaultRedactFields = []string{
+	"email",
+	"firstName",
+	"lastName",
+	"phone",
+	"User.name",
+}
+
+// Redactor masks the credentials, session ids and configured PII fields of Bill.com requests and responses.
+// Field names are matched case-insensitively, at any depth of the JSON documents, and so are the values of
+// the filters on these fields. A field can be scoped to an entity, like `User.name`, to leave the field of other
+// entities, like the names of organizations and roles, readable. A nil Redactor masks the secret fields and the
+// DefaultRedactFields.
+type Redactor struct {
+	fields map[string]bool
+	// entityFields are the fields masked in the objects of an entity, keyed by entity.
+	entityFields map[string]map[string]bool
+}
+
+// NewRedactor returns a Redactor masking the secret fields and the provided PII fields, like `email` or
+// `User.name`.
+func NewRedactor(piiFields ...string) *Redactor {
+	r := &Redactor{
+		fields:       make(map[string]bool),
+		entityFields: make(map[string]map[string]bool),
+	}
+
+	for _, field := range secretFields {
+		r.fields[strings.ToLower(field)] = true
+	}
+
+	for _, field := range piiFields {
+		field = strings.ToLower(strings.TrimSpace(field))
+		if field == "" {
+			continue
+		}
+
+		entity, entityField, ok := strings.Cut(field, ".")
+		if !ok {
+			r.fields[field] = true
+			continue
+		}
+
+		if r.entityFields[entity] == nil {
+			r.entityFields[entity] = make(map[string]bool)
+		}
+		r.entityFields[entity][entityField] = true
+	}
+
+	return r
+}
+
+var defaultRedactor = NewRedactor(DefaultRedactFields...)
+
+// redacts reports whether the field of an object of the entity is masked. The entity may be empty when it's
+// unknown.
+func (r *Redactor) redacts(entity string, field string) bool {
+	if r == nil {
+		r = defaultRedactor
+	}
+
+	field = strings.ToLower(field)
+
+	return r.fields[field] || r.entityFields[strings.ToLower(entity)][field]
+}
+
+// Form returns a copy of the form body of a request to the endpoint path with the fields masked, including the
+// fields of the JSON `data` document.
+func (r *Redactor) Form(path string, form url.Values) url.Values {
+	rv := url.Values{}
+
+	for key, values := range form {
+		switch {
+		case r.redacts("", key):
+			rv[key] = []string{Redacted}
+		case key == "data":
+			redactedValues := make([]string, 0, len(values))
+			for _, value := range values {
+				redactedValues = append(redactedValues, string(r.JSON(path, []byte(value))))
+			}
+			rv[key] = redactedValues
+		default:
+			rv[key] = values
+		}
+	}
+
+	return rv
+}
+
+// JSON returns the JSON document of a request to, or a response from, the endpoint path with the fields masked.
+// Objects belong to the entity named by their `entity` field, or else to the entity of the path, like `User` for
+// /List/User.json. Documents that are not valid JSON are returned unchanged.
+func (r *Redactor) JSON(path string, raw []byte) []byte {
+	var document interface{}
+	if err := json.Unmarshal(raw, &document); err != nil {
+		return raw
+	}
+
+	redacted, err := json.Marshal(r.value(pathEntity(path), document))
+	if err != nil {
+		return raw
+	}
+
+	return redacted
+}
+
+func (r *Redactor) value(entity string, value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if objEntity, ok := v["entity"].(string); ok {
+			entity = objEntity
+		}
+
+		// filters hold the name of the field they match in `field` and the PII in `value`
+		if field, ok := v["field"].(string); ok && r.redacts(entity, field) {
+			if _, ok := v["value"]; ok {
+				v["value"] = Redacted
+			}
+		}
+
+		for key, field := range v {
+			if r.redacts(entity, key) {
+				v[key] = Redacted
+				continue
+			}
+
+			v[key] = r.value(entity, field)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = r.value(entity, item)
+		}
+	}
+
+	return value
+}
+
+// pathEntity returns the entity of the endpoint path, like `User` for /api/v2/Crud/Read/User.json.
+func pathEntity(endpoint string) string {
+	return strings.TrimSuffix(path.Base(endpoint), ".json")
+}
@@ -0,0 +1,172 @@
+package bill_test
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/ConductorOne/baton-bill/pkg/bill"
+)
+
+const listUsersPath = "/api/v2/List/User.json"
+
+func loginForm() url.Values {
+	return url.Values{
+		"userName": {"integration@example.com"},
+		"password": {"hunter2"},
+		"devKey":   {"dev-key"},
+		"orgId":    {"org-1"},
+	}
+}
+
+func TestRedactorMasksForms(t *testing.T) {
+	tests := []struct {
+		name     string
+		redactor *bill.Redactor
+		path     string
+		form     url.Values
+		masked   []string
+		kept     []string
+	}{
+		{
+			name:     "credentials",
+			redactor: bill.NewRedactor(),
+			path:     "/api/v2/Login.json",
+			form:     loginForm(),
+			masked:   []string{"integration@example.com", "hunter2", "dev-key"},
+			kept:     []string{"org-1"},
+		},
+		{
+			name:     "email filter",
+			redactor: bill.NewRedactor(bill.DefaultRedactFields...),
+			path:     listUsersPath,
+			form: url.Values{
+				"sessionId": {"session-1"},
+				"data":      {`{"start":0,"max":999,"filters":[{"field":"email","op":"=","value":"ada@example.com"}]}`},
+			},
+			masked: []string{"session-1", "ada@example.com"},
+			kept:   []string{`"field":"email"`, `"max":999`},
+		},
+		{
+			name:     "filter on a field that isn't masked",
+			redactor: bill.NewRedactor(bill.DefaultRedactFields...),
+			path:     listUsersPath,
+			form:     url.Values{"data": {`{"filters":[{"field":"isActive","op":"=","value":"1"}]}`}},
+			kept:     []string{`"value":"1"`},
+		},
+		{
+			name:     "user object",
+			redactor: bill.NewRedactor(bill.DefaultRedactFields...),
+			path:     "/api/v2/Crud/Update/User.json",
+			form: url.Values{
+				"data": {`{"obj":{"entity":"User","id":"usr-1","firstName":"Ada","lastName":"Admin","email":"ada@example.com","profileId":"pro-1"}}`},
+			},
+			masked: []string{"Ada", "Admin", "ada@example.com"},
+			kept:   []string{"usr-1", "pro-1"},
+		},
+		{
+			name:     "secrets only",
+			redactor: bill.NewRedactor(),
+			path:     "/api/v2/Crud/Update/User.json",
+			form: url.Values{
+				"sessionId": {"session-1"},
+				"data":      {`{"obj":{"entity":"User","id":"usr-1","email":"ada@example.com"}}`},
+			},
+			masked: []string{"session-1"},
+			kept:   []string{"ada@example.com"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.redactor.Form(tt.path, tt.form).Encode()
+			got, err := url.QueryUnescape(got)
+			if err != nil {
+				t.Fatalf("QueryUnescape: %v", err)
+			}
+
+			for _, value := range tt.masked {
+				if strings.Contains(got, value) {
+					t.Errorf("got %s, want %q masked", got, value)
+				}
+			}
+
+			for _, value := range tt.kept {
+				if !strings.Contains(got, value) {
+					t.Errorf("got %s, want %q kept", got, value)
+				}
+			}
+		})
+	}
+}
+
+func TestRedactorMasksResponses(t *testing.T) {
+	tests := []struct {
+		name   string
+		path   string
+		body   string
+		masked []string
+		kept   []string
+	}{
+		{
+			name:   "users",
+			path:   listUsersPath,
+			body:   `{"response_status":0,"response_data":[{"id":"usr-1","name":"Ada Admin","email":"ada@example.com","phone":"555-0100"}]}`,
+			masked: []string{"Ada Admin", "ada@example.com", "555-0100"},
+			kept:   []string{"usr-1"},
+		},
+		{
+			name:   "user entity",
+			path:   "/api/v2/GetSessionInfo.json",
+			body:   `{"response_data":{"user":{"entity":"User","name":"Ada Admin"}}}`,
+			masked: []string{"Ada Admin"},
+		},
+		{
+			name: "organizations",
+			path: "/api/v2/ListOrgs.json",
+			body: `{"response_data":[{"orgId":"org-1","orgName":"Acme"}]}`,
+			kept: []string{"Acme"},
+		},
+		{
+			name: "roles",
+			path: "/api/v2/List/Profile.json",
+			body: `{"response_data":[{"id":"pro-1","name":"Administrator"}]}`,
+			kept: []string{"Administrator"},
+		},
+		{
+			name: "invalid JSON",
+			path: listUsersPath,
+			body: `<html>email ada@example.com</html>`,
+			kept: []string{"ada@example.com"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// a nil Redactor masks the default fields
+			var redactor *bill.Redactor
+			got := string(redactor.JSON(tt.path, []byte(tt.body)))
+
+			for _, value := range tt.masked {
+				if strings.Contains(got, value) {
+					t.Errorf("got %s, want %q masked", got, value)
+				}
+			}
+
+			for _, value := range tt.kept {
+				if !strings.Contains(got, value) {
+					t.Errorf("got %s, want %q kept", got, value)
+				}
+			}
+		})
+	}
+}
+
+func TestRedactorMatchesFieldsCaseInsensitively(t *testing.T) {
+	redactor := bill.NewRedactor(" Email ", "user.NAME")
+
+	got := string(redactor.JSON(listUsersPath, []byte(`[{"EMAIL":"ada@example.com","Name":"Ada Admin","SessionID":"session-1"}]`)))
+	if strings.Contains(got, "ada@example.com") || strings.Contains(got, "Ada Admin") || strings.Contains(got, "session-1") {
+		t.Errorf("got %s, want every value masked", got)
+	}
+}
@@ -1,83 +1,132 @@
 package bill
 
 import (
+	"encoding/json"
 	"net/url"
-	"strconv"
 )
 
 type RequestOption interface {
-	Apply(*url.Values)
+	Apply(*RequestBody)
+}
+
+// RequestBody collects the form fields of a request and the JSON document sent in its `data` field.
+// Bill.com v2 endpoints take the credentials as form fields and everything else in `data`.
+type RequestBody struct {
+	form url.Values
+	data map[string]interface{}
+}
+
+func newRequestBody() *RequestBody {
+	return &RequestBody{
+		form: url.Values{},
+		data: make(map[string]interface{}),
+	}
+}
+
+// SetField sets a form field of the request.
+func (body *RequestBody) SetField(key string, value string) {
+	body.form.Set(key, value)
+}
+
+// SetData sets a field of the JSON `data` document of the request.
+func (body *RequestBody) SetData(key string, value interface{}) {
+	body.data[key] = value
+}
+
+// Encode returns the form encoded request body, with the `data` document serialized as JSON.
+func (body *RequestBody) Encode() (string, error) {
+	form := url.Values{}
+	for key, values := range body.form {
+		form[key] = values
+	}
+
+	if len(body.data) > 0 {
+		data, err := json.Marshal(body.data)
+		if err != nil {
+			return "", err
+		}
+
+		form.Set("data", string(data))
+	}
+
+	return form.Encode(), nil
 }
 
 // Method Apply for Credentials struct adds credentials to the request body.
-func (credentials Credentials) Apply(body *url.Values) {
+func (credentials Credentials) Apply(body *RequestBody) {
 	// add username (required for login)
 	if credentials.Username != "" {
-		body.Set("userName", credentials.Username)
+		body.SetField("userName", credentials.Username)
 	}
 
 	// add password (required for login)
 	if credentials.Password != "" {
-		body.Set("password", credentials.Password)
+		body.SetField("password", credentials.Password)
 	}
 
 	// add organization id (required for login)
 	if credentials.OrganizationId != "" {
-		body.Set("orgId", credentials.OrganizationId)
+		body.SetField("orgId", credentials.OrganizationId)
 	}
 
 	// add developer key (required for login)
 	if credentials.DeveloperKey != "" {
-		body.Set("devKey", credentials.DeveloperKey)
+		body.SetField("devKey", credentials.DeveloperKey)
 	}
 
 	// add session id
 	if credentials.SessionId != "" {
-		body.Set("sessionId", credentials.SessionId)
+		body.SetField("sessionId", credentials.SessionId)
 	}
-}
-
-// Method Apply for PaginationParams struct adds pagination parameters to the request body.
-func (pagination PaginationParams) Apply(body *url.Values) {
-	data := url.Values{}
 
-	// add max reference
-	if pagination.Max != 0 {
-		data.Set("max", strconv.Itoa(pagination.Max))
+	// add the remembered MFA id and device id (required for MFA-trusted login)
+	if credentials.MFAId != "" {
+		body.SetField("mfaId", credentials.MFAId)
 	}
 
-	// add start reference
-	if pagination.Start != 0 {
-		data.Set("start", strconv.Itoa(pagination.Start))
+	if credentials.DeviceId != "" {
+		body.SetField("deviceId", credentials.DeviceId)
 	}
+}
 
-	body.Set("data", data.Encode())
+// Method Apply for PaginationParams struct adds pagination parameters to the request data.
+func (pagination PaginationParams) Apply(body *RequestBody) {
+	// List endpoints require both the start and max references
+	if pagination.Max != 0 {
+		body.SetData("start", pagination.Start)
+		body.SetData("max", pagination.Max)
+	}
 }
 
-// Method Apply for SearchParams struct adds search parameters (like id of the resource) to the request body.
-// In case of Bill.com API, it uses the data field to pass the search parameters as well as the pagination parameters
-// So this function handles both cases.
-func (searchParams SearchParams) Apply(body *url.Values) {
+// Method Apply for SearchParams struct adds search parameters (like id of the resource, filters and sorting)
+// to the request data.
+func (searchParams SearchParams) Apply(body *RequestBody) {
 	// add Id if provided
 	if searchParams.Id != "" {
-		// check if the data field is already set
-		if body.Has("data") {
-			data, err := url.ParseQuery(body.Get("data"))
-			if err != nil {
-				return
-			}
-
-			data.Set("id", searchParams.Id)
-			body.Set("data", data.Encode())
-		} else {
-			data := url.Values{}
-
-			data.Set("id", searchParams.Id)
-			body.Set("data", data.Encode())
-		}
+		body.SetData("id", searchParams.Id)
+	}
+
+	if len(searchParams.Filters) > 0 {
+		body.SetData("filters", searchParams.Filters)
+	}
+
+	if len(searchParams.Sort) > 0 {
+		body.SetData("sort", searchParams.Sort)
+	}
+
+	if searchParams.Nested {
+		body.SetData("nested", true)
+	}
+
+	if searchParams.ShowAudit {
+		body.SetData("showAudit", true)
 	}
+}
 
-	// TODO: add filtering and sorting?
+// Method Apply for UserParams struct adds both pagination and search parameters to the request data.
+func (userParams UserParams) Apply(body *RequestBody) {
+	userParams.PaginationParams.Apply(body)
+	userParams.SearchParams.Apply(body)
 }
 
 func IsInvalidResponse[T any](response BaseResponse[T]) bool {
@@ -0,0 +1,95 @@
+package bill
+
+import (
+	"sync"
+)
+
+// Session represents a logged in Bill.com API session scoped to a single organization.
+type Session struct {
+	Id             string `json:"id"`
+	OrganizationId string `json:"organizationId"`
+	// MFATrusted is set for sessions that can perform operations requiring multi-factor authentication.
+	MFATrusted bool `json:"mfaTrusted"`
+}
+
+// SessionManager keeps track of one session per organization id and is safe for concurrent use.
+type SessionManager struct {
+	mtx      sync.Mutex
+	sessions map[string]*Session
+	orgLocks map[string]*sync.Mutex
+}
+
+func NewSessionManager() *SessionManager {
+	return &SessionManager{
+		sessions: make(map[string]*Session),
+		orgLocks: make(map[string]*sync.Mutex),
+	}
+}
+
+// Get returns the session stored for the organization, if there is one.
+func (m *SessionManager) Get(organizationId string) (*Session, bool) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	session, ok := m.sessions[organizationId]
+
+	return session, ok
+}
+
+// Set stores the session under its organization id, replacing any previous one.
+func (m *SessionManager) Set(session *Session) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	m.sessions[session.OrganizationId] = session
+}
+
+// Delete removes the session stored for the organization.
+func (m *SessionManager) Delete(organizationId string) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	delete(m.sessions, organizationId)
+}
+
+// MarkMFATrusted marks the session stored for the organization as MFA-trusted.
+func (m *SessionManager) MarkMFATrusted(organizationId string) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	// replace the session with a copy, so callers holding the old one don't see it change
+	if session, ok := m.sessions[organizationId]; ok {
+		trusted := *session
+		trusted.MFATrusted = true
+		m.sessions[organizationId] = &trusted
+	}
+}
+
+// All returns all the sessions currently held by the manager.
+func (m *SessionManager) All() []*Session {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	rv := make([]*Session, 0, len(m.sessions))
+	for _, session := range m.sessions {
+		rv = append(rv, session)
+	}
+
+	return rv
+}
+
+// lockOrganization serializes logins into the same organization, so that concurrent callers share one session.
+// It returns the function that releases the lock.
+func (m *SessionManager) lockOrganization(organizationId string) func() {
+	m.mtx.Lock()
+	orgLock, ok := m.orgLocks[organizationId]
+	if !ok {
+		orgLock = &sync.Mutex{}
+		m.orgLocks[organizationId] = orgLock
+	}
+	m.mtx.Unlock()
+
+	orgLock.Lock()
+
+	return orgLock.Unlock
+}
@@ -0,0 +1,170 @@
+package bill
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// SessionCache persists sessions between runs, so the connector doesn't have to log in every time.
+type SessionCache interface {
+	Load(key string) (*Session, bool, error)
+	Store(key string, session *Session) error
+	Delete(key string) error
+}
+
+// SessionCacheKeySize is the size of the AES-256 key encrypting the session cache file.
+const SessionCacheKeySize = 32
+
+// SessionCacheKey returns the cache key of the session of the user in the organization of the Bill.com API at
+// baseURL. Sessions of the sandbox and the production API never share a key.
+func SessionCacheKey(baseURL string, username string, developerKey string, organizationId string) string {
+	hash := sha256.Sum256([]byte(baseURL + "\x00" + username + "\x00" + developerKey + "\x00" + organizationId))
+
+	return hex.EncodeToString(hash[:])
+}
+
+// FileSessionCache stores the sessions in a single file encrypted with AES-GCM.
+type FileSessionCache struct {
+	path string
+	aead cipher.AEAD
+	mtx  sync.Mutex
+}
+
+// NewFileSessionCache returns a cache stored at path and encrypted with the key, which must be SessionCacheKeySize
+// random bytes.
+func NewFileSessionCache(path string, key []byte) (*FileSessionCache, error) {
+	if len(key) != SessionCacheKeySize {
+		return nil, fmt.Errorf("bill: session cache key must be %d bytes, got %d", SessionCacheKeySize, len(key))
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FileSessionCache{
+		path: path,
+		aead: aead,
+	}, nil
+}
+
+func (c *FileSessionCache) Load(key string) (*Session, bool, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	sessions, err := c.read()
+	if err != nil {
+		return nil, false, err
+	}
+
+	session, ok := sessions[key]
+
+	return session, ok, nil
+}
+
+func (c *FileSessionCache) Store(key string, session *Session) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	sessions, err := c.read()
+	if err != nil {
+		return err
+	}
+
+	sessions[key] = session
+
+	return c.write(sessions)
+}
+
+func (c *FileSessionCache) Delete(key string) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	sessions, err := c.read()
+	if err != nil {
+		return err
+	}
+
+	if _, ok := sessions[key]; !ok {
+		return nil
+	}
+
+	delete(sessions, key)
+
+	return c.write(sessions)
+}
+
+func (c *FileSessionCache) read() (map[string]*Session, error) {
+	sessions := make(map[string]*Session)
+
+	data, err := os.ReadFile(c.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return sessions, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	nonceSize := c.aead.NonceSize()
+	if len(data) < nonceSize {
+		return nil, fmt.Errorf("bill: session cache %s is corrupt", c.path)
+	}
+
+	plaintext, err := c.aead.Open(nil, data[:nonceSize], data[nonceSize:], nil)
+	if err != nil {
+		return nil, fmt.Errorf("bill: failed to decrypt session cache %s: %w", c.path, err)
+	}
+
+	if err := json.Unmarshal(plaintext, &sessions); err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+// write encrypts the sessions and replaces the cache file, so a crash never leaves a partially written file.
+func (c *FileSessionCache) write(sessions map[string]*Session) error {
+	plaintext, err := json.Marshal(sessions)
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return err
+	}
+
+	data := c.aead.Seal(nonce, nonce, plaintext, nil)
+
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), filepath.Base(c.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), c.path)
+}
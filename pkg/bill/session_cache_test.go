@@ -0,0 +1,145 @@
+package bill_test
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/ConductorOne/baton-bill/pkg/bill"
+	"github.com/ConductorOne/baton-bill/pkg/bill/billtest"
+)
+
+// newCacheKey returns a session cache key made of the byte repeated.
+func newCacheKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, bill.SessionCacheKeySize)
+}
+
+// newRunClient returns a client of the server, like the one of a new run of the connector.
+func newRunClient(server *billtest.Server, cache bill.SessionCache) *bill.Client {
+	fixtures := billtest.DefaultFixtures()
+
+	return bill.NewClient(
+		server.Client(),
+		bill.Credentials{
+			Username:     fixtures.Username,
+			Password:     fixtures.Password,
+			DeveloperKey: fixtures.DeveloperKey,
+		},
+		bill.WithBaseURL(server.BaseURL()),
+		bill.WithSessionCache(cache),
+	)
+}
+
+func TestFileSessionCacheStoresEncryptedSessions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions")
+
+	cache, err := bill.NewFileSessionCache(path, newCacheKey(1))
+	if err != nil {
+		t.Fatalf("NewFileSessionCache: %v", err)
+	}
+
+	if err := cache.Store("key", &bill.Session{Id: "session-1", OrganizationId: "org-1"}); err != nil {
+		t.Fatalf("Store: %v", err)
+	}
+
+	reopened, err := bill.NewFileSessionCache(path, newCacheKey(1))
+	if err != nil {
+		t.Fatalf("NewFileSessionCache: %v", err)
+	}
+
+	session, ok, err := reopened.Load("key")
+	if err != nil || !ok {
+		t.Fatalf("Load: got %v, %v, want the stored session", ok, err)
+	}
+
+	if session.Id != "session-1" || session.OrganizationId != "org-1" {
+		t.Errorf("got session %+v, want the stored session", session)
+	}
+
+	otherKey, err := bill.NewFileSessionCache(path, newCacheKey(2))
+	if err != nil {
+		t.Fatalf("NewFileSessionCache: %v", err)
+	}
+
+	if _, _, err := otherKey.Load("key"); err == nil {
+		t.Error("loaded the sessions with another key, want a decryption error")
+	}
+
+	if err := reopened.Delete("key"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	if _, ok, _ := cache.Load("key"); ok {
+		t.Error("the session is still cached after Delete")
+	}
+}
+
+func TestNewFileSessionCacheRequiresAFullSizeKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions")
+
+	for _, key := range [][]byte{nil, []byte("passphrase"), newCacheKey(1)[1:]} {
+		if _, err := bill.NewFileSessionCache(path, key); err == nil {
+			t.Errorf("accepted a %d-byte key", len(key))
+		}
+	}
+}
+
+func TestSessionCacheKeyDependsOnTheBaseURL(t *testing.T) {
+	production := bill.SessionCacheKey(bill.BaseURL, "integration@example.com", "dev-key", "org-1")
+	sandbox := bill.SessionCacheKey(bill.SandboxBaseURL, "integration@example.com", "dev-key", "org-1")
+
+	if production == sandbox {
+		t.Error("got the same key for the production and the sandbox sessions")
+	}
+}
+
+func TestClientReusesCachedSessions(t *testing.T) {
+	ctx := context.Background()
+
+	server := billtest.NewServer(billtest.DefaultFixtures())
+	t.Cleanup(server.Close)
+
+	cache, err := bill.NewFileSessionCache(filepath.Join(t.TempDir(), "sessions"), newCacheKey(1))
+	if err != nil {
+		t.Fatalf("NewFileSessionCache: %v", err)
+	}
+
+	for run := 0; run < 2; run++ {
+		if _, err := newRunClient(server, cache).GetUsers(ctx, "org-1", firstPage()); err != nil {
+			t.Fatalf("GetUsers of run %d: %v", run, err)
+		}
+	}
+
+	if got := server.Requests("/Login.json"); got != 1 {
+		t.Errorf("got %d logins, want the session of the first run reused", got)
+	}
+}
+
+func TestClientLogsInAgainWhenTheCachedSessionIsStale(t *testing.T) {
+	ctx := context.Background()
+
+	server := billtest.NewServer(billtest.DefaultFixtures())
+	t.Cleanup(server.Close)
+
+	cache := &memorySessionCache{sessions: make(map[string]bill.Session)}
+
+	if _, err := newRunClient(server, cache).GetUsers(ctx, "org-1", firstPage()); err != nil {
+		t.Fatalf("GetUsers: %v", err)
+	}
+
+	server.ExpireSessions()
+
+	users, err := newRunClient(server, cache).GetUsers(ctx, "org-1", firstPage())
+	if err != nil {
+		t.Fatalf("GetUsers with a stale cached session: %v", err)
+	}
+
+	if len(users) != 3 {
+		t.Errorf("got %d users, want 3", len(users))
+	}
+
+	if got := server.Requests("/Login.json"); got != 2 {
+		t.Errorf("got %d logins, want the stale session replaced by a new login", got)
+	}
+}
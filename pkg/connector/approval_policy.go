@@ -0,0 +1,204 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ConductorOne/baton-bill/pkg/bill"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	ent "github.com/conductorone/baton-sdk/pkg/types/entitlement"
+	grant "github.com/conductorone/baton-sdk/pkg/types/grant"
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+)
+
+const approvalPolicyApprover = "approver"
+
+type approvalPolicyResourceType struct {
+	resourceType *v2.ResourceType
+	client       *bill.Client
+}
+
+func (a *approvalPolicyResourceType) ResourceType(_ context.Context) *v2.ResourceType {
+	return a.resourceType
+}
+
+// Create a new connector resource for a Bill Approval Policy of the organization.
+// The approvers are the user ids in the order they approve.
+func approvalPolicyResource(
+	ctx context.Context,
+	policy *bill.ApprovalPolicy,
+	approvers []bill.ApprovalPolicyApprover,
+	organizationId string,
+) (*v2.Resource, error) {
+	approvalOrder := make([]interface{}, 0, len(approvers))
+	for _, approver := range approvers {
+		if approver.IsActive {
+			approvalOrder = append(approvalOrder, approver.UserId)
+		}
+	}
+
+	profile := map[string]interface{}{
+		"approval_policy_id": policy.Id,
+		"organization_id":    organizationId,
+		"name":               policy.Name,
+		"object_type":        policy.ObjectType,
+		"is_active":          bool(policy.IsActive),
+		"approval_order":     approvalOrder,
+	}
+
+	// thresholds are only added when the policy has them
+	if policy.MinAmount != "" {
+		profile["min_amount"] = policy.MinAmount.String()
+	}
+
+	if policy.MaxAmount != "" {
+		profile["max_amount"] = policy.MaxAmount.String()
+	}
+
+	displayName := policy.Name
+	if displayName == "" {
+		displayName = fmt.Sprintf("%s Approval Policy %s", policy.ObjectType, policy.Id)
+	}
+
+	resource, err := rs.NewGroupResource(
+		displayName,
+		resourceTypeApprovalPolicy,
+		orgScopedId(organizationId, policy.Id),
+		[]rs.GroupTraitOption{rs.WithGroupProfile(profile)},
+		rs.WithParentResourceID(organizationResourceId(organizationId)),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return resource, nil
+}
+
+func (a *approvalPolicyResourceType) List(ctx context.Context, parentId *v2.ResourceId, token *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
+	if parentId == nil {
+		return nil, "", nil, nil
+	}
+
+	paginator, err := paginate(
+		token,
+		&v2.ResourceId{ResourceType: resourceTypeApprovalPolicy.Id},
+		func(ctx context.Context, params bill.PaginationParams) ([]bill.ApprovalPolicy, error) {
+			return a.client.GetApprovalPolicies(ctx, parentId.Resource, params)
+		},
+	)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	policies, err := paginator.Next(ctx)
+	if err != nil {
+		return nil, "", nil, wrapError(err, "failed to list approval policies")
+	}
+
+	var rv []*v2.Resource
+	for _, policy := range policies {
+		approvers, err := a.approvers(ctx, parentId.Resource, policy.Id)
+		if err != nil {
+			return nil, "", nil, wrapError(err, "failed to list approval policy approvers")
+		}
+
+		policyCopy := policy
+		ar, err := approvalPolicyResource(ctx, &policyCopy, approvers, parentId.Resource)
+		if err != nil {
+			return nil, "", nil, err
+		}
+
+		rv = append(rv, ar)
+	}
+
+	nextToken, err := paginator.NextToken()
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	return rv, nextToken, rateLimitAnnotations(a.client), nil
+}
+
+// approvers returns all the approvers of the approval policy, in approval order.
+func (a *approvalPolicyResourceType) approvers(ctx context.Context, organizationId string, approvalPolicyId string) ([]bill.ApprovalPolicyApprover, error) {
+	paginator, err := bill.Paginate(
+		&pagination.Bag{},
+		ResourcesPageSize,
+		func(ctx context.Context, params bill.PaginationParams) ([]bill.ApprovalPolicyApprover, error) {
+			return a.client.GetApprovalPolicyApprovers(ctx, organizationId, approvalPolicyId, params)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return paginator.All(ctx)
+}
+
+func (a *approvalPolicyResourceType) Entitlements(ctx context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
+	assignmentOptions := []ent.EntitlementOption{
+		ent.WithGrantableTo(resourceTypeUser),
+		ent.WithDisplayName(fmt.Sprintf("%s %s", resource.DisplayName, titleCaser.String(approvalPolicyApprover))),
+		ent.WithDescription(fmt.Sprintf("Approver in the %s Bill.com approval policy", resource.DisplayName)),
+	}
+
+	return []*v2.Entitlement{
+		ent.NewAssignmentEntitlement(resource, approvalPolicyApprover, assignmentOptions...),
+	}, "", nil, nil
+}
+
+// Grants returns a grant to every active approver of the approval policy.
+func (a *approvalPolicyResourceType) Grants(ctx context.Context, resource *v2.Resource, token *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
+	organizationId, approvalPolicyId, err := parseOrgScopedId(resource.Id)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	paginator, err := paginate(
+		token,
+		&v2.ResourceId{ResourceType: resourceTypeUser.Id},
+		func(ctx context.Context, params bill.PaginationParams) ([]bill.ApprovalPolicyApprover, error) {
+			return a.client.GetApprovalPolicyApprovers(ctx, organizationId, approvalPolicyId, params)
+		},
+	)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	approvers, err := paginator.Next(ctx)
+	if err != nil {
+		return nil, "", nil, wrapError(err, "failed to list approval policy approvers")
+	}
+
+	var rv []*v2.Grant
+	for _, approver := range approvers {
+		if !approver.IsActive || approver.UserId == "" {
+			continue
+		}
+
+		rv = append(rv, grant.NewGrant(
+			resource,
+			approvalPolicyApprover,
+			&v2.ResourceId{
+				ResourceType: resourceTypeUser.Id,
+				Resource:     orgScopedId(organizationId, approver.UserId),
+			},
+		))
+	}
+
+	nextToken, err := paginator.NextToken()
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	return rv, nextToken, rateLimitAnnotations(a.client), nil
+}
+
+func approvalPolicyBuilder(client *bill.Client) *approvalPolicyResourceType {
+	return &approvalPolicyResourceType{
+		resourceType: resourceTypeApprovalPolicy,
+		client:       client,
+	}
+}
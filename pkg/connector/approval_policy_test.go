@@ -0,0 +1,37 @@
+package connector_test
+
+import (
+	"slices"
+	"testing"
+
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+)
+
+func TestApprovalPolicyApproversInApprovalOrder(t *testing.T) {
+	_, b := newTestConnector(t)
+
+	policy := organizationChild(t, b, "approval_policy", "org-1:apo-1")
+
+	// the inactive approver org-1:usr-3 is left out
+	if got := grantees(t, b, policy, "approver"); !slices.Equal(got, []string{"org-1:usr-2", "org-1:usr-1"}) {
+		t.Errorf("got approvers %v, want org-1:usr-2 then org-1:usr-1", got)
+	}
+
+	trait, err := rs.GetGroupTrait(policy)
+	if err != nil {
+		t.Fatalf("GetGroupTrait: %v", err)
+	}
+
+	var order []string
+	for _, value := range trait.GetProfile().GetFields()["approval_order"].GetListValue().GetValues() {
+		order = append(order, value.GetStringValue())
+	}
+
+	if !slices.Equal(order, []string{"usr-2", "usr-1"}) {
+		t.Errorf("got approval order %v, want usr-2 then usr-1", order)
+	}
+
+	if minAmount, _ := rs.GetProfileStringValue(trait.GetProfile(), "min_amount"); minAmount != "1000" {
+		t.Errorf("got min_amount %q, want 1000", minAmount)
+	}
+}
@@ -9,8 +9,6 @@ import (
 	"github.com/conductorone/baton-sdk/pkg/connectorbuilder"
 	"github.com/conductorone/baton-sdk/pkg/uhttp"
 	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
 )
 
 var (
@@ -32,18 +30,100 @@ var (
 			v2.ResourceType_TRAIT_ROLE,
 		},
 	}
+	resourceTypePermission = &v2.ResourceType{
+		Id:          "permission",
+		DisplayName: "Permission",
+	}
+	resourceTypeApprovalPolicy = &v2.ResourceType{
+		Id:          "approval_policy",
+		DisplayName: "Approval Policy",
+		Traits: []v2.ResourceType_Trait{
+			v2.ResourceType_TRAIT_GROUP,
+		},
+	}
 )
 
 type Bill struct {
-	client *bill.Client
-	orgs   []string
+	client          *bill.Client
+	rolePermissions *rolePermissions
+	orgs            []string
+	clientOptions   []bill.ClientOption
+	recordDir       string
+	replayDir       string
+	redactor        *bill.Redactor
+
+	revokeFallbackRole string
+	defaultRole        string
+	protectedRoles     []string
+}
+
+// Option configures optional behavior of the Bill connector.
+type Option func(*Bill)
+
+// WithClientOptions passes the options to the Bill.com API client created by the connector.
+func WithClientOptions(opts ...bill.ClientOption) Option {
+	return func(b *Bill) {
+		b.clientOptions = append(b.clientOptions, opts...)
+	}
+}
+
+// WithRecordDir saves the redacted Bill.com requests and responses as fixture files in dir.
+func WithRecordDir(dir string) Option {
+	return func(b *Bill) {
+		b.recordDir = dir
+	}
+}
+
+// WithReplayDir serves the fixture files saved in dir instead of calling Bill.com.
+func WithReplayDir(dir string) Option {
+	return func(b *Bill) {
+		b.replayDir = dir
+	}
+}
+
+// WithRedactFields masks the provided PII fields, in addition to the credentials, in the logs and recordings
+// of Bill.com requests and responses. bill.DefaultRedactFields are masked if the option isn't set, and an empty
+// list only masks the credentials.
+func WithRedactFields(fields ...string) Option {
+	return func(b *Bill) {
+		b.redactor = bill.NewRedactor(fields...)
+	}
+}
+
+// WithRevokeFallbackRole sets the id or name of the role users are moved to when their role is revoked.
+// Bill.com users always have a role, so roles can't be revoked without it.
+func WithRevokeFallbackRole(role string) Option {
+	return func(b *Bill) {
+		b.revokeFallbackRole = role
+	}
+}
+
+// WithDefaultRole sets the id or name of the role of the users created when organization membership is granted.
+// A role annotation on the grant takes precedence.
+func WithDefaultRole(role string) Option {
+	return func(b *Bill) {
+		b.defaultRole = role
+	}
+}
+
+// WithProtectedRoles sets the ids, names or types of the roles an organization must keep an active user in.
+// Provisioning refuses changes that would leave no active user in any of them. DefaultProtectedRoles are
+// used if the option isn't set, and an empty list turns the check off.
+func WithProtectedRoles(roles ...string) Option {
+	return func(b *Bill) {
+		b.protectedRoles = roles
+	}
 }
 
 func (b *Bill) ResourceSyncers(ctx context.Context) []connectorbuilder.ResourceSyncer {
+	guard := newAdminGuard(b.client, b.protectedRoles)
+
 	return []connectorbuilder.ResourceSyncer{
-		organizationBuilder(b.client, b.orgs),
+		organizationBuilder(b.client, b.orgs, b.rolePermissions, b.defaultRole, guard),
 		userBuilder(b.client),
-		roleBuilder(b.client),
+		roleBuilder(b.client, b.rolePermissions, b.revokeFallbackRole, guard),
+		permissionBuilder(b.client, b.rolePermissions),
+		approvalPolicyBuilder(b.client),
 	}
 }
 
@@ -54,28 +134,58 @@ func (b *Bill) Metadata(ctx context.Context) (*v2.ConnectorMetadata, error) {
 }
 
 func (b *Bill) Validate(ctx context.Context) (annotations.Annotations, error) {
-	// TODO: research if this is run after `New` since we would have to login here.
-	_, err := b.client.GetSessionDetails(ctx)
-
-	if err != nil {
-		return nil, status.Error(codes.Unauthenticated, "Provided Access Token is invalid")
+	// Login to every configured organization to make sure the credentials are valid for all of them.
+	for _, organizationId := range b.orgs {
+		_, err := b.client.GetSessionDetails(ctx, organizationId)
+		if err != nil {
+			return nil, wrapError(err, "failed to validate credentials")
+		}
 	}
 
 	return nil, nil
 }
 
+// Close ends the Bill.com sessions opened by the connector.
+func (b *Bill) Close(ctx context.Context) error {
+	return b.client.Close(ctx)
+}
+
 // New returns the Bill connector.
-func New(ctx context.Context, organizationIds []string, credentials bill.Credentials) (*Bill, error) {
-	httpClient, err := uhttp.NewClient(ctx, uhttp.WithLogger(true, ctxzap.Extract(ctx)))
+func New(ctx context.Context, organizationIds []string, credentials bill.Credentials, opts ...Option) (*Bill, error) {
+	b := &Bill{
+		orgs:           organizationIds,
+		protectedRoles: DefaultProtectedRoles,
+		redactor:       bill.NewRedactor(bill.DefaultRedactFields...),
+	}
 
+	for _, opt := range opts {
+		opt(b)
+	}
+
+	// the uhttp logger is replaced by a logging transport that masks the credentials sent in every request
+	httpClient, err := uhttp.NewClient(ctx, uhttp.WithLogger(false, nil))
 	if err != nil {
 		return nil, err
 	}
 
-	billClient := bill.NewClient(httpClient, credentials)
+	if b.replayDir != "" {
+		httpClient.Transport, err = bill.NewReplayTransport(b.replayDir, b.redactor)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if b.recordDir != "" {
+		httpClient.Transport, err = bill.NewRecordingTransport(httpClient.Transport, b.recordDir, b.redactor)
+		if err != nil {
+			return nil, err
+		}
+	}
 
-	return &Bill{
-		client: billClient,
-		orgs:   organizationIds,
-	}, nil
+	httpClient.Transport = bill.NewLoggingTransport(httpClient.Transport, ctxzap.Extract(ctx), b.redactor)
+
+	b.client = bill.NewClient(httpClient, credentials, b.clientOptions...)
+	b.rolePermissions = newRolePermissions(b.client)
+
+	return b, nil
 }
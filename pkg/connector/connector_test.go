@@ -0,0 +1,194 @@
+package connector_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ConductorOne/baton-bill/pkg/bill"
+	"github.com/ConductorOne/baton-bill/pkg/bill/billtest"
+	"github.com/ConductorOne/baton-bill/pkg/connector"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/connectorbuilder"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	ent "github.com/conductorone/baton-sdk/pkg/types/entitlement"
+)
+
+// newTestConnector starts a fake Bill.com API with the default fixtures and returns a connector for all its
+// organizations.
+func newTestConnector(t *testing.T, opts ...connector.Option) (*billtest.Server, *connector.Bill) {
+	t.Helper()
+
+	fixtures := billtest.DefaultFixtures()
+
+	server := billtest.NewServer(fixtures)
+	t.Cleanup(server.Close)
+
+	opts = append([]connector.Option{connector.WithClientOptions(bill.WithBaseURL(server.BaseURL()))}, opts...)
+
+	b, err := connector.New(
+		context.Background(),
+		nil,
+		bill.Credentials{
+			Username:     fixtures.Username,
+			Password:     fixtures.Password,
+			DeveloperKey: fixtures.DeveloperKey,
+		},
+		opts...,
+	)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	return server, b
+}
+
+func syncer(t *testing.T, b *connector.Bill, resourceTypeId string) connectorbuilder.ResourceSyncer {
+	t.Helper()
+
+	ctx := context.Background()
+	for _, s := range b.ResourceSyncers(ctx) {
+		if s.ResourceType(ctx).Id == resourceTypeId {
+			return s
+		}
+	}
+
+	t.Fatalf("no syncer for %s", resourceTypeId)
+
+	return nil
+}
+
+// provisioner returns the syncer the SDK routes the grants and revokes of the entitlements of the resource to.
+func provisioner(t *testing.T, b *connector.Bill, resource *v2.Resource) connectorbuilder.ResourceProvisioner {
+	t.Helper()
+
+	p, ok := syncer(t, b, resource.Id.ResourceType).(connectorbuilder.ResourceProvisioner)
+	if !ok {
+		t.Fatalf("%s entitlements can't be provisioned", resource.Id.ResourceType)
+	}
+
+	return p
+}
+
+// findResource lists the resources of the type under the parent, walking every page, and returns the one with the id.
+func findResource(t *testing.T, b *connector.Bill, resourceTypeId string, parentId *v2.ResourceId, id string) *v2.Resource {
+	t.Helper()
+
+	s := syncer(t, b, resourceTypeId)
+	token := ""
+	for {
+		resources, nextToken, _, err := s.List(context.Background(), parentId, &pagination.Token{Token: token})
+		if err != nil {
+			t.Fatalf("List %s: %v", resourceTypeId, err)
+		}
+
+		for _, resource := range resources {
+			if resource.Id.Resource == id {
+				return resource
+			}
+		}
+
+		if nextToken == "" {
+			break
+		}
+		token = nextToken
+	}
+
+	t.Fatalf("%s %s not found", resourceTypeId, id)
+
+	return nil
+}
+
+func organization(t *testing.T, b *connector.Bill, organizationId string) *v2.Resource {
+	t.Helper()
+
+	return findResource(t, b, "organization", nil, organizationId)
+}
+
+func organizationChild(t *testing.T, b *connector.Bill, resourceTypeId string, id string) *v2.Resource {
+	t.Helper()
+
+	organizationId, _, _ := strings.Cut(id, ":")
+
+	return findResource(t, b, resourceTypeId, organization(t, b, organizationId).Id, id)
+}
+
+func entitlement(t *testing.T, b *connector.Bill, resource *v2.Resource, slug string) *v2.Entitlement {
+	t.Helper()
+
+	entitlements, _, _, err := syncer(t, b, resource.Id.ResourceType).Entitlements(context.Background(), resource, &pagination.Token{})
+	if err != nil {
+		t.Fatalf("Entitlements: %v", err)
+	}
+
+	for _, e := range entitlements {
+		if e.Id == ent.NewEntitlementID(resource, slug) {
+			return e
+		}
+	}
+
+	t.Fatalf("entitlement %s of %s not found", slug, resource.Id.Resource)
+
+	return nil
+}
+
+// grantees returns the ids of the principals granted the entitlement of the resource, walking every page.
+func grantees(t *testing.T, b *connector.Bill, resource *v2.Resource, slug string) []string {
+	t.Helper()
+
+	var rv []string
+
+	s := syncer(t, b, resource.Id.ResourceType)
+	token := ""
+	for {
+		grants, nextToken, _, err := s.Grants(context.Background(), resource, &pagination.Token{Token: token})
+		if err != nil {
+			t.Fatalf("Grants: %v", err)
+		}
+
+		for _, grant := range grants {
+			if grant.Entitlement.Id == ent.NewEntitlementID(resource, slug) {
+				rv = append(rv, grant.Principal.Id.Resource)
+			}
+		}
+
+		if nextToken == "" {
+			return rv
+		}
+		token = nextToken
+	}
+}
+
+func grant(t *testing.T, b *connector.Bill, resource *v2.Resource, slug string, principalId string) *v2.Grant {
+	t.Helper()
+
+	s := syncer(t, b, resource.Id.ResourceType)
+	grants, _, _, err := s.Grants(context.Background(), resource, &pagination.Token{})
+	if err != nil {
+		t.Fatalf("Grants: %v", err)
+	}
+
+	for _, g := range grants {
+		if g.Entitlement.Id == ent.NewEntitlementID(resource, slug) && g.Principal.Id.Resource == principalId {
+			return g
+		}
+	}
+
+	t.Fatalf("grant of %s %s to %s not found", resource.Id.Resource, slug, principalId)
+
+	return nil
+}
+
+func user(t *testing.T, server *billtest.Server, organizationId string, userId string) bill.User {
+	t.Helper()
+
+	for _, u := range server.Users(organizationId) {
+		if u.Id == userId {
+			return u
+		}
+	}
+
+	t.Fatalf("user %s not found in %s", userId, organizationId)
+
+	return bill.User{}
+}
@@ -1,16 +1,28 @@
 package connector
 
 import (
-	"strconv"
+	"errors"
+	"fmt"
+	"strings"
 
+	"github.com/ConductorOne/baton-bill/pkg/bill"
 	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
 	"github.com/conductorone/baton-sdk/pkg/pagination"
 	"golang.org/x/text/cases"
 	"golang.org/x/text/language"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 var ResourcesPageSize = 50
 
+// usersSearchParams sorts users by id, so pages stay stable while the sync is running.
+var usersSearchParams = bill.SearchParams{
+	Sort: []bill.Sort{{Field: "id", Asc: true}},
+}
+
 func parsePageToken(i string, resourceID *v2.ResourceId) (*pagination.Bag, error) {
 	b := &pagination.Bag{}
 	err := b.Unmarshal(i)
@@ -28,18 +40,77 @@ func parsePageToken(i string, resourceID *v2.ResourceId) (*pagination.Bag, error
 	return b, nil
 }
 
-func handlePageToken(token *pagination.Token) (int, error) {
-	bag, err := parsePageToken(token.Token, &v2.ResourceId{ResourceType: resourceTypeUser.Id})
+// paginate returns a paginator over the list endpoint that resumes at the position stored in the page token.
+func paginate[T bill.Identifiable](token *pagination.Token, resourceID *v2.ResourceId, list bill.ListFunc[T]) (*bill.Paginator[T], error) {
+	bag, err := parsePageToken(token.Token, resourceID)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
-	page, err := strconv.Atoi(bag.PageToken())
-	if err != nil {
-		return 0, err
+	return bill.Paginate(bag, ResourcesPageSize, list)
+}
+
+// orgScopedIdSeparator separates the organization id from the entity id in user and role resource ids.
+const orgScopedIdSeparator = ":"
+
+// orgScopedId returns the resource id of a Bill.com user or role. Their ids are prefixed with the id of the
+// organization, because the same user or standard profile shows up in every organization it belongs to.
+func orgScopedId(organizationId string, entityId string) string {
+	return organizationId + orgScopedIdSeparator + entityId
+}
+
+// parseOrgScopedId returns the organization id and the Bill.com entity id of a user or role resource.
+func parseOrgScopedId(resourceId *v2.ResourceId) (string, string, error) {
+	organizationId, entityId, ok := strings.Cut(resourceId.GetResource(), orgScopedIdSeparator)
+	if !ok || organizationId == "" || entityId == "" {
+		return "", "", fmt.Errorf("bill-connector: invalid %s resource id %s", resourceId.GetResourceType(), resourceId.GetResource())
+	}
+
+	return organizationId, entityId, nil
+}
+
+// organizationResourceId returns the resource id of the organization, the parent of its users and roles.
+func organizationResourceId(organizationId string) *v2.ResourceId {
+	return &v2.ResourceId{
+		ResourceType: resourceTypeOrganization.Id,
+		Resource:     organizationId,
+	}
+}
+
+// grpcCoder is implemented by the errors of the Bill.com API client that map to a gRPC code.
+type grpcCoder interface {
+	error
+	GRPCCode() codes.Code
+}
+
+// wrapError adds context to the error, keeping the gRPC code of Bill.com API errors so the cause stays visible.
+func wrapError(err error, message string) error {
+	var coder grpcCoder
+	if errors.As(err, &coder) {
+		return status.Errorf(coder.GRPCCode(), "bill-connector: %s: %s", message, coder.Error())
 	}
 
-	return page, nil
+	return fmt.Errorf("bill-connector: %s: %w", message, err)
+}
+
+// rateLimitAnnotations describes the request budget of the client, so the syncer can see the Bill.com rate limit.
+func rateLimitAnnotations(client *bill.Client) annotations.Annotations {
+	rateLimit := client.RateLimitStatus()
+
+	rateLimitStatus := v2.RateLimitDescription_STATUS_OK
+	if rateLimit.Limited {
+		rateLimitStatus = v2.RateLimitDescription_STATUS_OVERLIMIT
+	}
+
+	var annos annotations.Annotations
+	annos.WithRateLimiting(&v2.RateLimitDescription{
+		Status:    rateLimitStatus,
+		Limit:     rateLimit.Limit,
+		Remaining: rateLimit.Remaining,
+		ResetAt:   timestamppb.New(rateLimit.ResetAt),
+	})
+
+	return annos
 }
 
 var titleCaser = cases.Title(language.English)
@@ -0,0 +1,97 @@
+package connector
+
+import (
+	"context"
+	"strings"
+
+	"github.com/ConductorOne/baton-bill/pkg/bill"
+)
+
+// Results of offboarding a user from an organization.
+const (
+	OffboardDeactivated     = "deactivated"
+	OffboardPlanned         = "would deactivate"
+	OffboardAlreadyInactive = "already inactive"
+	OffboardNotFound        = "not found"
+	OffboardFailed          = "failed"
+)
+
+// OffboardResult is the outcome of offboarding a person from one organization. UserId is empty when the
+// organization has no user with the email, or when looking the user up failed.
+type OffboardResult struct {
+	OrganizationId   string
+	OrganizationName string
+	UserId           string
+	Result           string
+	Err              error
+}
+
+// Offboard deactivates the users with the email in every organization the credentials can access, not only the
+// configured ones. A failure in one organization doesn't stop the others, it is reported in its result.
+// In dry-run mode, the users are looked up but not deactivated.
+func (b *Bill) Offboard(ctx context.Context, email string) ([]OffboardResult, error) {
+	organizations, err := b.client.GetOrganizations(ctx)
+	if err != nil {
+		return nil, wrapError(err, "failed to list organizations")
+	}
+
+	guard := newAdminGuard(b.client, b.protectedRoles)
+
+	var rv []OffboardResult
+	for _, organization := range organizations {
+		failed := OffboardResult{
+			OrganizationId:   organization.Id,
+			OrganizationName: organization.Name,
+			Result:           OffboardFailed,
+		}
+
+		users, err := listAll(ctx, func(ctx context.Context, params bill.PaginationParams) ([]bill.User, error) {
+			return b.client.GetUsersByEmail(ctx, organization.Id, email, params)
+		})
+		if err != nil {
+			failed.Err = wrapError(err, "failed to find user")
+			rv = append(rv, failed)
+			continue
+		}
+
+		found := false
+		for _, user := range users {
+			// the email filter of the API may not be case-sensitive, or exact
+			if !strings.EqualFold(user.Email, email) {
+				continue
+			}
+
+			found = true
+			result := OffboardResult{
+				OrganizationId:   organization.Id,
+				OrganizationName: organization.Name,
+				UserId:           user.Id,
+			}
+
+			deactivated, err := deactivateUser(ctx, b.client, guard, organization.Id, user)
+			switch {
+			case err != nil:
+				result.Result = OffboardFailed
+				result.Err = err
+			case !deactivated:
+				result.Result = OffboardAlreadyInactive
+			case b.client.DryRun():
+				result.Result = OffboardPlanned
+			default:
+				result.Result = OffboardDeactivated
+			}
+
+			rv = append(rv, result)
+		}
+
+		if !found {
+			rv = append(rv, OffboardResult{
+				OrganizationId:   organization.Id,
+				OrganizationName: organization.Name,
+				Result:           OffboardNotFound,
+			})
+		}
+	}
+
+	return rv, nil
+}
@@ -0,0 +1,63 @@
+package connector_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ConductorOne/baton-bill/pkg/bill"
+	"github.com/ConductorOne/baton-bill/pkg/bill/billtest"
+	"github.com/ConductorOne/baton-bill/pkg/connector"
+)
+
+func TestOffboardContinuesAfterAFailedOrganization(t *testing.T) {
+	ctx := context.Background()
+	// without protected roles, so Ada can be deactivated in org-2, where she's the only administrator
+	server, b := newTestConnector(t, connector.WithProtectedRoles())
+
+	server.InjectError("/List/User.json", billtest.InjectedError{Code: "BDC_1102", Message: "Permission denied."})
+
+	results, err := b.Offboard(ctx, "ada@example.com")
+	if err != nil {
+		t.Fatalf("Offboard: %v", err)
+	}
+
+	got := make(map[string]string)
+	for _, result := range results {
+		got[result.OrganizationId] = result.Result
+	}
+
+	if got["org-1"] != connector.OffboardFailed || got["org-2"] != connector.OffboardDeactivated {
+		t.Fatalf("got results %v, want org-1 failed and org-2 deactivated", got)
+	}
+
+	if user(t, server, "org-2", "usr-4").IsActive {
+		t.Error("got an active user in org-2, want the user deactivated")
+	}
+}
+
+func TestOffboardDryRun(t *testing.T) {
+	ctx := context.Background()
+	server, b := newTestConnector(t,
+		connector.WithClientOptions(bill.WithDryRun(true)),
+		connector.WithProtectedRoles(),
+	)
+
+	results, err := b.Offboard(ctx, "ada@example.com")
+	if err != nil {
+		t.Fatalf("Offboard: %v", err)
+	}
+
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2", len(results))
+	}
+
+	for _, result := range results {
+		if result.Result != connector.OffboardPlanned {
+			t.Errorf("got %q in %s, want %q", result.Result, result.OrganizationId, connector.OffboardPlanned)
+		}
+	}
+
+	if got := server.Requests("/Crud/Update/User.json"); got != 0 {
+		t.Errorf("got %d user updates, want 0", got)
+	}
+}
@@ -3,7 +3,6 @@ package connector
 import (
 	"context"
 	"fmt"
-	"strconv"
 
 	"github.com/ConductorOne/baton-bill/pkg/bill"
 	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
@@ -12,6 +11,10 @@ import (
 	ent "github.com/conductorone/baton-sdk/pkg/types/entitlement"
 	grant "github.com/conductorone/baton-sdk/pkg/types/grant"
 	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 const orgRoleMember = "member"
@@ -20,6 +23,11 @@ type organizationResourceType struct {
 	resourceType *v2.ResourceType
 	client       *bill.Client
 	orgs         map[string]*bill.Organization
+	// rolePermissions is reset when organizations are listed, at the start of every sync.
+	rolePermissions *rolePermissions
+	// defaultRole is the id or name of the role of users created by Grant, unless the grant sets one.
+	defaultRole string
+	guard       *adminGuard
 }
 
 func (o *organizationResourceType) ResourceType(_ context.Context) *v2.ResourceType {
@@ -36,6 +44,8 @@ func organizationResource(ctx context.Context, organization *bill.Organization,
 		rs.WithAnnotation(
 			&v2.ChildResourceType{ResourceTypeId: resourceTypeUser.Id},
 			&v2.ChildResourceType{ResourceTypeId: resourceTypeRole.Id},
+			&v2.ChildResourceType{ResourceTypeId: resourceTypePermission.Id},
+			&v2.ChildResourceType{ResourceTypeId: resourceTypeApprovalPolicy.Id},
 		),
 	)
 
@@ -47,10 +57,12 @@ func organizationResource(ctx context.Context, organization *bill.Organization,
 }
 
 func (o *organizationResourceType) List(ctx context.Context, parentId *v2.ResourceId, _ *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
+	o.rolePermissions.reset()
+
 	// Listing organization in Bill does not support pagination
 	organizations, err := o.client.GetOrganizations(ctx)
 	if err != nil {
-		return nil, "", nil, fmt.Errorf("bill-connector: failed to list organizations: %w", err)
+		return nil, "", nil, wrapError(err, "failed to list organizations")
 	}
 
 	var rv []*v2.Resource
@@ -59,12 +71,6 @@ func (o *organizationResourceType) List(ctx context.Context, parentId *v2.Resour
 			continue
 		}
 
-		// Login to specific organization in Bill
-		err = o.client.Login(ctx, organization.Id)
-		if err != nil {
-			return nil, "", nil, fmt.Errorf("bill-connector: failed to login to organization: %w", err)
-		}
-
 		organizationCopy := organization
 		or, err := organizationResource(ctx, &organizationCopy, parentId)
 
@@ -75,7 +81,7 @@ func (o *organizationResourceType) List(ctx context.Context, parentId *v2.Resour
 		rv = append(rv, or)
 	}
 
-	return rv, "", nil, nil
+	return rv, "", rateLimitAnnotations(o.client), nil
 }
 
 func (o *organizationResourceType) Entitlements(ctx context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
@@ -98,24 +104,32 @@ func (o *organizationResourceType) Entitlements(ctx context.Context, resource *v
 }
 
 func (o *organizationResourceType) Grants(ctx context.Context, resource *v2.Resource, token *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
-	page, err := handlePageToken(token)
+	paginator, err := paginate(
+		token,
+		&v2.ResourceId{ResourceType: resourceTypeUser.Id},
+		func(ctx context.Context, params bill.PaginationParams) ([]bill.User, error) {
+			return o.client.GetUsers(ctx, resource.Id.Resource, bill.UserParams{PaginationParams: params, SearchParams: usersSearchParams})
+		},
+	)
 	if err != nil {
 		return nil, "", nil, err
 	}
 
-	users, nextPage, err := o.client.GetUsers(ctx, bill.PaginationParams{
-		Start: page,
-		Max:   ResourcesPageSize,
-	})
+	users, err := paginator.Next(ctx)
 	if err != nil {
-		return nil, "", nil, fmt.Errorf("bill-connector: failed to get users: %w", err)
+		return nil, "", nil, wrapError(err, "failed to get users")
 	}
 
 	var rv []*v2.Grant
 	for _, user := range users {
+		// inactive users aren't members, Revoke deactivates users instead of deleting them
+		if !user.IsActive {
+			continue
+		}
+
 		userCopy := user
 
-		ur, err := userResource(ctx, &userCopy, nil)
+		ur, err := userResource(ctx, &userCopy, resource.Id.Resource)
 		if err != nil {
 			return nil, "", nil, err
 		}
@@ -128,10 +142,142 @@ func (o *organizationResourceType) Grants(ctx context.Context, resource *v2.Reso
 		))
 	}
 
-	return rv, strconv.Itoa(nextPage), nil, nil
+	nextToken, err := paginator.NextToken()
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	return rv, nextToken, rateLimitAnnotations(o.client), nil
+}
+
+// Grant adds the user to the organization. The user is matched by email: an inactive user is reactivated, and
+// a new user is created with the default role when the organization has none.
+func (o *organizationResourceType) Grant(ctx context.Context, principal *v2.Resource, entitlement *v2.Entitlement) (annotations.Annotations, error) {
+	l := ctxzap.Extract(ctx)
+
+	if principal.Id.ResourceType != resourceTypeUser.Id {
+		return nil, status.Errorf(codes.InvalidArgument, "bill-connector: organization membership can only be granted to users, not %s", principal.Id.ResourceType)
+	}
+
+	organizationId := entitlement.GetResource().GetId().GetResource()
+
+	newUser, err := userFromPrincipal(principal)
+	if err != nil {
+		return nil, err
+	}
+
+	logFields := []zap.Field{
+		zap.String("organization_id", organizationId),
+		zap.String("principal_id", principal.Id.Resource),
+	}
+
+	users, err := listAll(ctx, func(ctx context.Context, params bill.PaginationParams) ([]bill.User, error) {
+		return o.client.GetUsersByEmail(ctx, organizationId, newUser.Email, params)
+	})
+	if err != nil {
+		return nil, wrapError(err, "failed to find user")
+	}
+
+	for _, user := range users {
+		if user.IsActive {
+			l.Info("bill-connector: user is already an active member of the organization", append(logFields, zap.String("user_id", user.Id))...)
+			return rateLimitAnnotations(o.client), nil
+		}
+	}
+
+	if len(users) > 0 {
+		user := users[0]
+		user.IsActive = true
+
+		l.Info("bill-connector: reactivating user", append(logFields, zap.String("user_id", user.Id))...)
+
+		_, err = o.client.UpdateUser(ctx, organizationId, user)
+		if err != nil {
+			return nil, wrapError(err, "failed to reactivate user")
+		}
+
+		return rateLimitAnnotations(o.client), nil
+	}
+
+	if o.defaultRole == "" {
+		return nil, status.Errorf(codes.FailedPrecondition, "bill-connector: can't create a user for %s, no default role is configured", principal.Id.Resource)
+	}
+
+	role, err := findRole(ctx, o.client, organizationId, o.defaultRole)
+	if err != nil {
+		return nil, err
+	}
+
+	newUser.RoleId = role.Id
+
+	l.Info("bill-connector: creating user", append(logFields, zap.String("role_id", role.Id))...)
+
+	_, err = o.client.CreateUser(ctx, organizationId, newUser)
+	if err != nil {
+		return nil, wrapError(err, "failed to create user")
+	}
+
+	return rateLimitAnnotations(o.client), nil
+}
+
+// Revoke deactivates the user. Users are not deleted, so their history stays in the Bill.com audit trail.
+func (o *organizationResourceType) Revoke(ctx context.Context, grant *v2.Grant) (annotations.Annotations, error) {
+	organizationId := grant.GetEntitlement().GetResource().GetId().GetResource()
+
+	userOrganizationId, userId, err := parseOrgScopedId(grant.GetPrincipal().GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	if userOrganizationId != organizationId {
+		return nil, status.Errorf(codes.InvalidArgument, "bill-connector: user %s isn't in the organization %s", userId, organizationId)
+	}
+
+	user, err := o.client.GetUser(ctx, organizationId, userId)
+	if err != nil {
+		return nil, wrapError(err, "failed to get user")
+	}
+
+	_, err = deactivateUser(ctx, o.client, o.guard, organizationId, user)
+	if err != nil {
+		return nil, err
+	}
+
+	return rateLimitAnnotations(o.client), nil
+}
+
+// deactivateUser sets the user inactive, unless that would leave the organization without an active user in a
+// protected role. It reports whether the user was active.
+func deactivateUser(ctx context.Context, client *bill.Client, guard *adminGuard, organizationId string, user bill.User) (bool, error) {
+	l := ctxzap.Extract(ctx)
+
+	logFields := []zap.Field{
+		zap.String("organization_id", organizationId),
+		zap.String("user_id", user.Id),
+	}
+
+	if !user.IsActive {
+		l.Info("bill-connector: user is already inactive", logFields...)
+		return false, nil
+	}
+
+	if err := guard.checkChange(ctx, organizationId, user, ""); err != nil {
+		return false, err
+	}
+
+	l.Info("bill-connector: deactivating user", logFields...)
+
+	user.IsActive = false
+
+	_, err := client.UpdateUser(ctx, organizationId, user)
+	if err != nil {
+		return false, wrapError(err, "failed to deactivate user")
+	}
+
+	return true, nil
 }
 
-func organizationBuilder(client *bill.Client, organizationIds []string) *organizationResourceType {
+func organizationBuilder(client *bill.Client, organizationIds []string, rolePermissions *rolePermissions, defaultRole string, guard *adminGuard) *organizationResourceType {
 	orgsMap := make(map[string]*bill.Organization)
 
 	for _, orgId := range organizationIds {
@@ -139,8 +285,11 @@ func organizationBuilder(client *bill.Client, organizationIds []string) *organiz
 	}
 
 	return &organizationResourceType{
-		resourceType: resourceTypeOrganization,
-		client:       client,
-		orgs:         orgsMap,
+		resourceType:    resourceTypeOrganization,
+		client:          client,
+		orgs:            orgsMap,
+		rolePermissions: rolePermissions,
+		defaultRole:     defaultRole,
+		guard:           guard,
 	}
 }
@@ -0,0 +1,182 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/ConductorOne/baton-bill/pkg/bill"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	ent "github.com/conductorone/baton-sdk/pkg/types/entitlement"
+	grant "github.com/conductorone/baton-sdk/pkg/types/grant"
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+)
+
+const permissionAssigned = "assigned"
+
+type permissionResourceType struct {
+	resourceType    *v2.ResourceType
+	client          *bill.Client
+	rolePermissions *rolePermissions
+}
+
+func (p *permissionResourceType) ResourceType(_ context.Context) *v2.ResourceType {
+	return p.resourceType
+}
+
+// Create a new connector resource for a Bill permission of the organization.
+func permissionResource(ctx context.Context, key string, organizationId string) (*v2.Resource, error) {
+	info := describePermission(key)
+
+	resource, err := rs.NewResource(
+		info.Name,
+		resourceTypePermission,
+		orgScopedId(organizationId, key),
+		rs.WithParentResourceID(organizationResourceId(organizationId)),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	resource.Description = fmt.Sprintf("%s (%s)", info.Description, info.Category)
+
+	return resource, nil
+}
+
+// List returns the permissions found on any role of the organization. Bill.com has no endpoint listing them,
+// so they are collected from the permissions of every role.
+func (p *permissionResourceType) List(ctx context.Context, parentId *v2.ResourceId, _ *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
+	if parentId == nil {
+		return nil, "", nil, nil
+	}
+
+	paginator, err := bill.Paginate(
+		&pagination.Bag{},
+		ResourcesPageSize,
+		func(ctx context.Context, params bill.PaginationParams) ([]bill.UserRoleProfile, error) {
+			return p.client.GetUserRoleProfiles(ctx, parentId.Resource, params)
+		},
+	)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	roles, err := paginator.All(ctx)
+	if err != nil {
+		return nil, "", nil, wrapError(err, "failed to list user roles")
+	}
+
+	keys := make(map[string]bool)
+	for _, role := range roles {
+		userRolePermissions, err := p.rolePermissions.get(ctx, parentId.Resource, role.Id)
+		if err != nil {
+			return nil, "", nil, wrapError(err, "failed to get user role permissions")
+		}
+
+		for pName := range userRolePermissions {
+			keys[pName] = true
+		}
+	}
+
+	sortedKeys := make([]string, 0, len(keys))
+	for key := range keys {
+		sortedKeys = append(sortedKeys, key)
+	}
+	sort.Strings(sortedKeys)
+
+	var rv []*v2.Resource
+	for _, key := range sortedKeys {
+		pr, err := permissionResource(ctx, key, parentId.Resource)
+		if err != nil {
+			return nil, "", nil, err
+		}
+
+		rv = append(rv, pr)
+	}
+
+	return rv, "", rateLimitAnnotations(p.client), nil
+}
+
+func (p *permissionResourceType) Entitlements(ctx context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
+	_, key, err := parseOrgScopedId(resource.Id)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	info := describePermission(key)
+
+	permissionOptions := []ent.EntitlementOption{
+		ent.WithGrantableTo(resourceTypeRole),
+		ent.WithDisplayName(fmt.Sprintf("%s Permission", info.Name)),
+		ent.WithDescription(fmt.Sprintf("%s: %s", info.Category, info.Description)),
+	}
+
+	return []*v2.Entitlement{
+		ent.NewPermissionEntitlement(resource, permissionAssigned, permissionOptions...),
+	}, "", nil, nil
+}
+
+// Grants returns a grant to every role of the organization that has the permission enabled.
+func (p *permissionResourceType) Grants(ctx context.Context, resource *v2.Resource, token *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
+	organizationId, key, err := parseOrgScopedId(resource.Id)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	paginator, err := paginate(
+		token,
+		&v2.ResourceId{ResourceType: resourceTypeRole.Id},
+		func(ctx context.Context, params bill.PaginationParams) ([]bill.UserRoleProfile, error) {
+			return p.client.GetUserRoleProfiles(ctx, organizationId, params)
+		},
+	)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	roles, err := paginator.Next(ctx)
+	if err != nil {
+		return nil, "", nil, wrapError(err, "failed to list user roles")
+	}
+
+	var rv []*v2.Grant
+	for _, role := range roles {
+		userRolePermissions, err := p.rolePermissions.get(ctx, organizationId, role.Id)
+		if err != nil {
+			return nil, "", nil, wrapError(err, "failed to get user role permissions")
+		}
+
+		if !userRolePermissions[key] {
+			continue
+		}
+
+		roleCopy := role
+		rr, err := roleResource(ctx, &roleCopy, organizationId)
+		if err != nil {
+			return nil, "", nil, err
+		}
+
+		rv = append(rv, grant.NewGrant(
+			resource,
+			permissionAssigned,
+			rr.Id,
+		))
+	}
+
+	nextToken, err := paginator.NextToken()
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	return rv, nextToken, rateLimitAnnotations(p.client), nil
+}
+
+func permissionBuilder(client *bill.Client, rolePermissions *rolePermissions) *permissionResourceType {
+	return &permissionResourceType{
+		resourceType:    resourceTypePermission,
+		client:          client,
+		rolePermissions: rolePermissions,
+	}
+}
@@ -0,0 +1,109 @@
+package connector
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Categories of the Bill.com permissions.
+const (
+	permissionCategoryPayables    = "Payables"
+	permissionCategoryReceivables = "Receivables"
+	permissionCategorySettings    = "Settings"
+	permissionCategoryUsers       = "Users"
+	permissionCategoryBanking     = "Banking"
+)
+
+type permissionInfo struct {
+	Name        string
+	Description string
+	Category    string
+}
+
+// permissionCatalog describes the well known keys returned by GetProfilePermissions.
+// Keys missing from the catalog are described by describePermission from the key itself.
+var permissionCatalog = map[string]permissionInfo{
+	"createBills":        {"Create Bills", "Enter and edit bills", permissionCategoryPayables},
+	"approveBills":       {"Approve Bills", "Approve bills assigned to the user in approval policies", permissionCategoryPayables},
+	"payBills":           {"Pay Bills", "Schedule and send payments for bills", permissionCategoryPayables},
+	"manageVendors":      {"Manage Vendors", "Add, edit and deactivate vendors", permissionCategoryPayables},
+	"createInvoices":     {"Create Invoices", "Create and send invoices to customers", permissionCategoryReceivables},
+	"manageCustomers":    {"Manage Customers", "Add, edit and deactivate customers", permissionCategoryReceivables},
+	"receivePayments":    {"Receive Payments", "Record and deposit customer payments", permissionCategoryReceivables},
+	"manageUsers":        {"Manage Users", "Invite users, change their roles and deactivate them", permissionCategoryUsers},
+	"manageRoles":        {"Manage Roles", "Create and edit user roles and their permissions", permissionCategoryUsers},
+	"manageBankAccounts": {"Manage Bank Accounts", "Add, verify and remove bank accounts", permissionCategoryBanking},
+	"viewBankAccounts":   {"View Bank Accounts", "See bank accounts and balances", permissionCategoryBanking},
+	"manageSettings":     {"Manage Settings", "Change the settings of the organization", permissionCategorySettings},
+	"syncAccounting":     {"Sync Accounting", "Sync with the accounting system", permissionCategorySettings},
+}
+
+// permissionCategoryKeywords assign a category to the permissions missing from the catalog.
+// The first matching keyword wins, so the more specific ones come first.
+var permissionCategoryKeywords = []struct {
+	keyword  string
+	category string
+}{
+	{"bank", permissionCategoryBanking},
+	{"fund", permissionCategoryBanking},
+	{"user", permissionCategoryUsers},
+	{"role", permissionCategoryUsers},
+	{"invoice", permissionCategoryReceivables},
+	{"customer", permissionCategoryReceivables},
+	{"receiv", permissionCategoryReceivables},
+	{"bill", permissionCategoryPayables},
+	{"vendor", permissionCategoryPayables},
+	{"pay", permissionCategoryPayables},
+}
+
+// describePermission returns the human-readable name, description and category of the permission key.
+func describePermission(key string) permissionInfo {
+	if info, ok := permissionCatalog[key]; ok {
+		return info
+	}
+
+	name := humanizePermissionKey(key)
+
+	category := permissionCategorySettings
+	lowerKey := strings.ToLower(key)
+	for _, k := range permissionCategoryKeywords {
+		if strings.Contains(lowerKey, k.keyword) {
+			category = k.category
+			break
+		}
+	}
+
+	return permissionInfo{
+		Name:        name,
+		Description: name + " in Bill.com",
+		Category:    category,
+	}
+}
+
+// humanizePermissionKey splits a camelCase key into words, e.g. approveBills becomes Approve Bills.
+func humanizePermissionKey(key string) string {
+	var words []string
+	var word []rune
+
+	for _, r := range key {
+		startsWord := r == '_' || (unicode.IsUpper(r) && len(word) > 0 && unicode.IsLower(word[len(word)-1]))
+		if startsWord && len(word) > 0 {
+			words = append(words, string(word))
+			word = nil
+		}
+
+		if r != '_' {
+			word = append(word, r)
+		}
+	}
+
+	if len(word) > 0 {
+		words = append(words, string(word))
+	}
+
+	for i, w := range words {
+		words[i] = titleCaser.String(w)
+	}
+
+	return strings.Join(words, " ")
+}
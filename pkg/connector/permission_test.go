@@ -0,0 +1,53 @@
+package connector_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+)
+
+func TestPermissionsAreListedFromEveryRole(t *testing.T) {
+	_, b := newTestConnector(t)
+
+	resources, _, _, err := syncer(t, b, "permission").List(context.Background(), organization(t, b, "org-1").Id, &pagination.Token{})
+	if err != nil {
+		t.Fatalf("List permissions: %v", err)
+	}
+
+	var got []string
+	for _, resource := range resources {
+		got = append(got, resource.Id.Resource)
+	}
+
+	want := []string{"org-1:approveBills", "org-1:createBills", "org-1:manageUsers", "org-1:payBills"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got permissions %v, want %v", got, want)
+	}
+}
+
+func TestPermissionsAreGrantedToTheRolesThatEnableThem(t *testing.T) {
+	server, b := newTestConnector(t)
+
+	tests := []struct {
+		id   string
+		want []string
+	}{
+		{"org-1:payBills", []string{"org-1:pro-1"}},
+		{"org-1:createBills", []string{"org-1:pro-2"}},
+	}
+
+	for _, tt := range tests {
+		permission := organizationChild(t, b, "permission", tt.id)
+
+		if got := grantees(t, b, permission, "assigned"); !slices.Equal(got, tt.want) {
+			t.Errorf("got %s granted to %v, want %v", tt.id, got, tt.want)
+		}
+	}
+
+	// every lookup lists the organizations like a new sync, which fetches the permissions of the 2 roles once
+	if got := server.Requests("/GetProfilePermissions.json"); got != 4 {
+		t.Errorf("got %d permission requests, want 2 per sync", got)
+	}
+}
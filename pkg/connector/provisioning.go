@@ -0,0 +1,133 @@
+package connector
+
+import (
+	"context"
+	"strings"
+
+	"github.com/ConductorOne/baton-bill/pkg/bill"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// userFromPrincipal returns the Bill.com user to create for the principal of an organization grant, from its
+// user trait. The principal is usually the same person's user in another organization.
+func userFromPrincipal(principal *v2.Resource) (bill.UserCreate, error) {
+	var user bill.UserCreate
+
+	userTrait, err := rs.GetUserTrait(principal)
+	if err != nil {
+		return user, status.Errorf(codes.InvalidArgument, "bill-connector: user %s has no user trait", principal.Id.Resource)
+	}
+
+	for _, email := range userTrait.Emails {
+		if user.Email == "" || email.IsPrimary {
+			user.Email = email.Address
+		}
+	}
+
+	if user.Email == "" {
+		user.Email, _ = rs.GetProfileStringValue(userTrait.Profile, "email")
+	}
+
+	user.FirstName, _ = rs.GetProfileStringValue(userTrait.Profile, "first_name")
+	user.LastName, _ = rs.GetProfileStringValue(userTrait.Profile, "last_name")
+
+	// fall back to the display name for principals synced from other connectors
+	if user.FirstName == "" && user.LastName == "" {
+		user.FirstName, user.LastName, _ = strings.Cut(strings.TrimSpace(principal.DisplayName), " ")
+	}
+
+	if user.Email == "" || user.FirstName == "" || user.LastName == "" {
+		return user, status.Errorf(codes.InvalidArgument, "bill-connector: user %s needs an email, a first name and a last name", principal.Id.Resource)
+	}
+
+	return user, nil
+}
+
+// DefaultProtectedRoles are the roles an organization must keep an active user in.
+var DefaultProtectedRoles = []string{"Administrator"}
+
+// adminGuard refuses changes that would leave an organization without any active user in a protected role,
+// which would lock the company out of its Bill.com organization.
+type adminGuard struct {
+	client *bill.Client
+	// protectedRoles are matched against the id, the name (case-insensitively) and the type of the roles.
+	protectedRoles []string
+}
+
+func newAdminGuard(client *bill.Client, protectedRoles []string) *adminGuard {
+	return &adminGuard{
+		client:         client,
+		protectedRoles: protectedRoles,
+	}
+}
+
+func (g *adminGuard) isProtected(role bill.UserRoleProfile) bool {
+	for _, protected := range g.protectedRoles {
+		if protected == role.Id || protected == role.Type || strings.EqualFold(protected, role.Name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkChange returns a FailedPrecondition error if moving the user to the new role, or deactivating the user
+// when newRoleId is empty, would leave the organization without an active user in a protected role.
+func (g *adminGuard) checkChange(ctx context.Context, organizationId string, user bill.User, newRoleId string) error {
+	if len(g.protectedRoles) == 0 || !user.IsActive {
+		return nil
+	}
+
+	roles, err := listAll(ctx, func(ctx context.Context, params bill.PaginationParams) ([]bill.UserRoleProfile, error) {
+		return g.client.GetUserRoleProfiles(ctx, organizationId, params)
+	})
+	if err != nil {
+		return wrapError(err, "failed to list user roles")
+	}
+
+	protectedRoleIds := make(map[string]bool)
+	for _, role := range roles {
+		if g.isProtected(role) {
+			protectedRoleIds[role.Id] = true
+		}
+	}
+
+	if !protectedRoleIds[user.RoleId] || (newRoleId != "" && protectedRoleIds[newRoleId]) {
+		return nil
+	}
+
+	users, err := listAll(ctx, func(ctx context.Context, params bill.PaginationParams) ([]bill.User, error) {
+		return g.client.GetUsers(ctx, organizationId, bill.UserParams{PaginationParams: params, SearchParams: usersSearchParams})
+	})
+	if err != nil {
+		return wrapError(err, "failed to list users")
+	}
+
+	for _, other := range users {
+		if other.Id != user.Id && bool(other.IsActive) && protectedRoleIds[other.RoleId] {
+			return nil
+		}
+	}
+
+	return status.Errorf(
+		codes.FailedPrecondition,
+		"bill-connector: refusing to change user %s, organization %s would be left without an active user in a protected role (%s)",
+		user.Id,
+		organizationId,
+		strings.Join(g.protectedRoles, ", "),
+	)
+}
+
+// listAll returns every entity of the list endpoint, walking all of its pages.
+func listAll[T bill.Identifiable](ctx context.Context, list bill.ListFunc[T]) ([]T, error) {
+	paginator, err := bill.Paginate(&pagination.Bag{}, ResourcesPageSize, list)
+	if err != nil {
+		return nil, err
+	}
+
+	return paginator.All(ctx)
+}
@@ -0,0 +1,243 @@
+package connector_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/ConductorOne/baton-bill/pkg/bill"
+	"github.com/ConductorOne/baton-bill/pkg/connector"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/connectorbuilder"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestGrantRoleReplacesTheRole(t *testing.T) {
+	ctx := context.Background()
+	server, b := newTestConnector(t)
+
+	admin := organizationChild(t, b, "role", "org-1:pro-1")
+	carl := organizationChild(t, b, "user", "org-1:usr-2")
+
+	if _, err := provisioner(t, b, admin).Grant(ctx, carl, entitlement(t, b, admin, "member")); err != nil {
+		t.Fatalf("Grant: %v", err)
+	}
+
+	if got := user(t, server, "org-1", "usr-2").RoleId; got != "pro-1" {
+		t.Errorf("got role %s, want pro-1", got)
+	}
+}
+
+func TestRevokeRoleMovesTheUserToTheFallbackRole(t *testing.T) {
+	ctx := context.Background()
+	server, b := newTestConnector(t, connector.WithRevokeFallbackRole("Clerk"))
+
+	admin := organizationChild(t, b, "role", "org-1:pro-1")
+	carl := organizationChild(t, b, "user", "org-1:usr-2")
+
+	if _, err := provisioner(t, b, admin).Grant(ctx, carl, entitlement(t, b, admin, "member")); err != nil {
+		t.Fatalf("Grant: %v", err)
+	}
+
+	if _, err := provisioner(t, b, admin).Revoke(ctx, grant(t, b, admin, "member", "org-1:usr-2")); err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+
+	if got := user(t, server, "org-1", "usr-2").RoleId; got != "pro-2" {
+		t.Errorf("got role %s, want the fallback role pro-2", got)
+	}
+}
+
+func TestRevokeRoleWithoutFallbackRole(t *testing.T) {
+	ctx := context.Background()
+	server, b := newTestConnector(t)
+
+	clerk := organizationChild(t, b, "role", "org-1:pro-2")
+
+	_, err := provisioner(t, b, clerk).Revoke(ctx, grant(t, b, clerk, "member", "org-1:usr-2"))
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("got error %v, want FailedPrecondition", err)
+	}
+
+	if got := server.Requests("/Crud/Update/User.json"); got != 0 {
+		t.Errorf("got %d user updates, want 0", got)
+	}
+}
+
+func TestRevokeRoleKeepsTheLastAdministrator(t *testing.T) {
+	ctx := context.Background()
+	server, b := newTestConnector(t, connector.WithRevokeFallbackRole("Clerk"))
+
+	admin := organizationChild(t, b, "role", "org-1:pro-1")
+
+	_, err := provisioner(t, b, admin).Revoke(ctx, grant(t, b, admin, "member", "org-1:usr-1"))
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("got error %v, want FailedPrecondition", err)
+	}
+
+	if got := user(t, server, "org-1", "usr-1").RoleId; got != "pro-1" {
+		t.Errorf("got role %s, want pro-1", got)
+	}
+}
+
+func TestGrantOrganizationCreatesTheUser(t *testing.T) {
+	ctx := context.Background()
+	server, b := newTestConnector(t, connector.WithDefaultRole("Administrator"))
+
+	europe := organization(t, b, "org-2")
+	carl := organizationChild(t, b, "user", "org-1:usr-2")
+
+	if _, err := provisioner(t, b, europe).Grant(ctx, carl, entitlement(t, b, europe, "member")); err != nil {
+		t.Fatalf("Grant: %v", err)
+	}
+
+	var created []bill.User
+	for _, u := range server.Users("org-2") {
+		if u.Email == "carl@example.com" {
+			created = append(created, u)
+		}
+	}
+
+	if len(created) != 1 {
+		t.Fatalf("got %d users for carl@example.com in org-2, want 1", len(created))
+	}
+
+	if !created[0].IsActive || created[0].RoleId != "pro-3" {
+		t.Errorf("got user active %t with role %s, want an active user with role pro-3", created[0].IsActive, created[0].RoleId)
+	}
+}
+
+func TestGrantOrganizationWithoutDefaultRole(t *testing.T) {
+	ctx := context.Background()
+	server, b := newTestConnector(t)
+
+	europe := organization(t, b, "org-2")
+	carl := organizationChild(t, b, "user", "org-1:usr-2")
+
+	_, err := provisioner(t, b, europe).Grant(ctx, carl, entitlement(t, b, europe, "member"))
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("got error %v, want FailedPrecondition", err)
+	}
+
+	if got := server.Requests("/Crud/Create/User.json"); got != 0 {
+		t.Errorf("got %d user creations, want 0", got)
+	}
+}
+
+func TestGrantOrganizationReactivatesTheUser(t *testing.T) {
+	ctx := context.Background()
+	server, b := newTestConnector(t)
+
+	acme := organization(t, b, "org-1")
+	dora := organizationChild(t, b, "user", "org-1:usr-3")
+
+	if _, err := provisioner(t, b, acme).Grant(ctx, dora, entitlement(t, b, acme, "member")); err != nil {
+		t.Fatalf("Grant: %v", err)
+	}
+
+	if !user(t, server, "org-1", "usr-3").IsActive {
+		t.Error("got an inactive user, want the user reactivated")
+	}
+
+	if got := server.Requests("/Crud/Create/User.json"); got != 0 {
+		t.Errorf("got %d user creations, want 0", got)
+	}
+}
+
+func TestRevokeOrganizationDeactivatesTheUser(t *testing.T) {
+	ctx := context.Background()
+	server, b := newTestConnector(t)
+
+	acme := organization(t, b, "org-1")
+
+	if members := grantees(t, b, acme, "member"); slices.Contains(members, "org-1:usr-3") {
+		t.Errorf("got members %v, want the inactive org-1:usr-3 left out", members)
+	}
+
+	if _, err := provisioner(t, b, acme).Revoke(ctx, grant(t, b, acme, "member", "org-1:usr-2")); err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+
+	if user(t, server, "org-1", "usr-2").IsActive {
+		t.Error("got an active user, want the user deactivated")
+	}
+
+	if members := grantees(t, b, acme, "member"); !slices.Equal(members, []string{"org-1:usr-1"}) {
+		t.Errorf("got members %v, want [org-1:usr-1]", members)
+	}
+}
+
+func TestRevokeOrganizationKeepsTheLastAdministrator(t *testing.T) {
+	ctx := context.Background()
+	server, b := newTestConnector(t)
+
+	europe := organization(t, b, "org-2")
+
+	_, err := provisioner(t, b, europe).Revoke(ctx, grant(t, b, europe, "member", "org-2:usr-4"))
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("got error %v, want FailedPrecondition", err)
+	}
+
+	if !user(t, server, "org-2", "usr-4").IsActive {
+		t.Error("got an inactive user, want the last administrator kept active")
+	}
+}
+
+func TestProvisioningDryRunDoesNotSendWrites(t *testing.T) {
+	ctx := context.Background()
+	server, b := newTestConnector(t,
+		connector.WithClientOptions(bill.WithDryRun(true)),
+		connector.WithRevokeFallbackRole("Clerk"),
+	)
+
+	admin := organizationChild(t, b, "role", "org-1:pro-1")
+	acme := organization(t, b, "org-1")
+	carl := organizationChild(t, b, "user", "org-1:usr-2")
+
+	if _, err := provisioner(t, b, admin).Grant(ctx, carl, entitlement(t, b, admin, "member")); err != nil {
+		t.Fatalf("Grant: %v", err)
+	}
+
+	if _, err := provisioner(t, b, acme).Revoke(ctx, grant(t, b, acme, "member", "org-1:usr-2")); err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+
+	if got := server.Requests("/Crud/Update/User.json"); got != 0 {
+		t.Errorf("got %d user updates, want 0", got)
+	}
+
+	if u := user(t, server, "org-1", "usr-2"); u.RoleId != "pro-2" || !u.IsActive {
+		t.Errorf("got user active %t with role %s, want it unchanged", u.IsActive, u.RoleId)
+	}
+}
+
+func TestConnectorRoutesGrantsToTheResourceTypes(t *testing.T) {
+	ctx := context.Background()
+	server, b := newTestConnector(t)
+
+	c, err := connectorbuilder.NewConnector(ctx, b)
+	if err != nil {
+		t.Fatalf("NewConnector: %v", err)
+	}
+
+	admin := organizationChild(t, b, "role", "org-1:pro-1")
+	carl := organizationChild(t, b, "user", "org-1:usr-2")
+
+	_, err = c.Grant(ctx, &v2.GrantManagerServiceGrantRequest{Principal: carl, Entitlement: entitlement(t, b, admin, "member")})
+	if err != nil {
+		t.Fatalf("Grant: %v", err)
+	}
+
+	if got := user(t, server, "org-1", "usr-2").RoleId; got != "pro-1" {
+		t.Errorf("got role %s, want pro-1", got)
+	}
+
+	ada := organizationChild(t, b, "user", "org-1:usr-1")
+	policy := organizationChild(t, b, "approval_policy", "org-1:apo-1")
+
+	_, err = c.Grant(ctx, &v2.GrantManagerServiceGrantRequest{Principal: ada, Entitlement: entitlement(t, b, policy, "approver")})
+	if err == nil {
+		t.Error("got no error granting an approval policy, want an error")
+	}
+}
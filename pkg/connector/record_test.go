@@ -0,0 +1,84 @@
+package connector_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ConductorOne/baton-bill/pkg/bill"
+	"github.com/ConductorOne/baton-bill/pkg/bill/billtest"
+	"github.com/ConductorOne/baton-bill/pkg/connector"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+)
+
+// userIds lists the ids of the users of the organization.
+func userIds(t *testing.T, b *connector.Bill, organizationId string) []string {
+	t.Helper()
+
+	users, _, _, err := syncer(t, b, "user").List(context.Background(), organization(t, b, organizationId).Id, &pagination.Token{})
+	if err != nil {
+		t.Fatalf("List users: %v", err)
+	}
+
+	var rv []string
+	for _, user := range users {
+		rv = append(rv, user.Id.Resource)
+	}
+
+	return rv
+}
+
+func TestConnectorReplaysRecordedSync(t *testing.T) {
+	dir := t.TempDir()
+	server, recording := newTestConnector(t, connector.WithRecordDir(dir))
+
+	recorded := userIds(t, recording, "org-1")
+	if len(recorded) != 3 {
+		t.Fatalf("got users %v, want the 3 users of org-1", recorded)
+	}
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil || len(paths) == 0 {
+		t.Fatalf("got recordings %v, %v, want the requests of the sync", paths, err)
+	}
+
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("ReadFile: %v", err)
+		}
+
+		for _, secret := range []string{"integration@example.com", "dev-key", "ada@example.com"} {
+			if strings.Contains(string(data), secret) {
+				t.Errorf("got %q recorded in %s", secret, filepath.Base(path))
+			}
+		}
+	}
+
+	// the replay never reaches the Bill.com API
+	baseURL := server.BaseURL()
+	server.Close()
+
+	fixtures := billtest.DefaultFixtures()
+	replay, err := connector.New(
+		context.Background(),
+		nil,
+		bill.Credentials{
+			Username:     fixtures.Username,
+			Password:     fixtures.Password,
+			DeveloperKey: fixtures.DeveloperKey,
+		},
+		connector.WithClientOptions(bill.WithBaseURL(baseURL)),
+		connector.WithReplayDir(dir),
+	)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	if replayed := userIds(t, replay, "org-1"); !slices.Equal(replayed, recorded) {
+		t.Errorf("got replayed users %v, want the recorded %v", replayed, recorded)
+	}
+}
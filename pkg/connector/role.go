@@ -3,7 +3,7 @@ package connector
 import (
 	"context"
 	"fmt"
-	"strconv"
+	"strings"
 
 	"github.com/ConductorOne/baton-bill/pkg/bill"
 	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
@@ -12,24 +12,33 @@ import (
 	ent "github.com/conductorone/baton-sdk/pkg/types/entitlement"
 	grant "github.com/conductorone/baton-sdk/pkg/types/grant"
 	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 const roleMember = "member"
 
 type roleResourceType struct {
-	resourceType *v2.ResourceType
-	client       *bill.Client
+	resourceType    *v2.ResourceType
+	client          *bill.Client
+	rolePermissions *rolePermissions
+	// revokeFallbackRole is the id or name of the role users are moved to when their role is revoked.
+	revokeFallbackRole string
+	guard              *adminGuard
 }
 
 func (o *roleResourceType) ResourceType(_ context.Context) *v2.ResourceType {
 	return o.resourceType
 }
 
-// Create a new connector resource for an Bill User Profile Role.
-func roleResource(ctx context.Context, role *bill.UserRoleProfile, parentResourceID *v2.ResourceId) (*v2.Resource, error) {
+// Create a new connector resource for an Bill User Profile Role of the organization.
+func roleResource(ctx context.Context, role *bill.UserRoleProfile, organizationId string) (*v2.Resource, error) {
 	profile := map[string]interface{}{
-		"role_id":   role.Id,
-		"role_name": role.Name,
+		"role_id":         role.Id,
+		"role_name":       role.Name,
+		"organization_id": organizationId,
 	}
 
 	roleTraitOptions := []rs.RoleTraitOption{
@@ -39,9 +48,9 @@ func roleResource(ctx context.Context, role *bill.UserRoleProfile, parentResourc
 	resource, err := rs.NewRoleResource(
 		role.Name,
 		resourceTypeRole,
-		role.Id,
+		orgScopedId(organizationId, role.Id),
 		roleTraitOptions,
-		rs.WithParentResourceID(parentResourceID),
+		rs.WithParentResourceID(organizationResourceId(organizationId)),
 	)
 
 	if err != nil {
@@ -56,24 +65,27 @@ func (o *roleResourceType) List(ctx context.Context, parentId *v2.ResourceId, to
 		return nil, "", nil, nil
 	}
 
-	page, err := handlePageToken(token)
+	paginator, err := paginate(
+		token,
+		&v2.ResourceId{ResourceType: resourceTypeRole.Id},
+		func(ctx context.Context, params bill.PaginationParams) ([]bill.UserRoleProfile, error) {
+			return o.client.GetUserRoleProfiles(ctx, parentId.Resource, params)
+		},
+	)
 	if err != nil {
 		return nil, "", nil, err
 	}
 
-	orgAccessRoles, nextPage, err := o.client.GetUserRoleProfiles(
-		ctx,
-		bill.PaginationParams{Max: ResourcesPageSize, Start: page},
-	)
+	orgAccessRoles, err := paginator.Next(ctx)
 	if err != nil {
-		return nil, "", nil, fmt.Errorf("bill-connector: failed to list user roles: %w", err)
+		return nil, "", nil, wrapError(err, "failed to list user roles")
 	}
 
 	var rv []*v2.Resource
 	for _, role := range orgAccessRoles {
 		roleCopy := role
 
-		rr, err := roleResource(ctx, &roleCopy, parentId)
+		rr, err := roleResource(ctx, &roleCopy, parentId.Resource)
 		if err != nil {
 			return nil, "", nil, err
 		}
@@ -81,7 +93,12 @@ func (o *roleResourceType) List(ctx context.Context, parentId *v2.ResourceId, to
 		rv = append(rv, rr)
 	}
 
-	return rv, strconv.Itoa(nextPage), nil, nil
+	nextToken, err := paginator.NextToken()
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	return rv, nextToken, rateLimitAnnotations(o.client), nil
 }
 
 func (o *roleResourceType) Entitlements(ctx context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
@@ -100,21 +117,15 @@ func (o *roleResourceType) Entitlements(ctx context.Context, resource *v2.Resour
 		assignmentOptions...,
 	))
 
-	// parse the role id from profile
-	roleTrait, err := rs.GetRoleTrait(resource)
+	organizationId, roleId, err := parseOrgScopedId(resource.Id)
 	if err != nil {
 		return nil, "", nil, err
 	}
 
-	roleId, ok := rs.GetProfileStringValue(roleTrait.Profile, "role_id")
-	if !ok {
-		return nil, "", nil, fmt.Errorf("bill-connector: failed to get role id from profile")
-	}
-
 	// add permissions entitlements
-	userRolePermissions, err := o.client.GetUserRolePermissions(ctx, roleId)
+	userRolePermissions, err := o.rolePermissions.get(ctx, organizationId, roleId)
 	if err != nil {
-		return nil, "", nil, fmt.Errorf("bill-connector: failed to get user role permissions: %w", err)
+		return nil, "", nil, wrapError(err, "failed to get user role permissions")
 	}
 
 	for pName, pValue := range userRolePermissions {
@@ -124,11 +135,12 @@ func (o *roleResourceType) Entitlements(ctx context.Context, resource *v2.Resour
 		}
 
 		// add entitlement for each permission
+		info := describePermission(pName)
 		rv = append(rv, ent.NewPermissionEntitlement(
 			resource,
 			pName,
-			ent.WithDisplayName(fmt.Sprintf("%s Permission %s", resource.DisplayName, titleCaser.String(pName))),
-			ent.WithDescription(fmt.Sprintf("%s Bill.com Permission", resource.DisplayName)),
+			ent.WithDisplayName(fmt.Sprintf("%s Permission %s", resource.DisplayName, info.Name)),
+			ent.WithDescription(fmt.Sprintf("%s Bill.com Permission: %s", resource.DisplayName, info.Description)),
 		))
 	}
 
@@ -136,40 +148,43 @@ func (o *roleResourceType) Entitlements(ctx context.Context, resource *v2.Resour
 }
 
 func (o *roleResourceType) Grants(ctx context.Context, resource *v2.Resource, token *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
-	page, err := handlePageToken(token)
+	organizationId, roleId, err := parseOrgScopedId(resource.Id)
 	if err != nil {
 		return nil, "", nil, err
 	}
 
-	// parse the role id from profile
-	roleTrait, err := rs.GetRoleTrait(resource)
+	// get all users and add membership grants for each user with the corresponding role
+	paginator, err := paginate(
+		token,
+		&v2.ResourceId{ResourceType: resourceTypeUser.Id},
+		func(ctx context.Context, params bill.PaginationParams) ([]bill.User, error) {
+			return o.client.GetUsers(ctx, organizationId, bill.UserParams{PaginationParams: params, SearchParams: usersSearchParams})
+		},
+	)
 	if err != nil {
 		return nil, "", nil, err
 	}
 
-	roleId, ok := rs.GetProfileStringValue(roleTrait.Profile, "role_id")
-	if !ok {
-		return nil, "", nil, fmt.Errorf("bill-connector: failed to get role id from profile")
-	}
-
-	// get all users and add membership grants for each user with the corresponding role
-	users, nextPage, err := o.client.GetUsers(ctx, bill.PaginationParams{
-		Start: page,
-		Max:   ResourcesPageSize,
-	})
+	users, err := paginator.Next(ctx)
 	if err != nil {
-		return nil, "", nil, fmt.Errorf("bill-connector: failed to get users: %w", err)
+		return nil, "", nil, wrapError(err, "failed to get users")
 	}
 
 	var rv []*v2.Grant
+	var userRolePermissions map[string]bool
 	for _, user := range users {
 		// skip if user does not have the role
 		if user.RoleId != roleId {
 			continue
 		}
 
+		// inactive users keep their role but can't use it, like they aren't members of the organization
+		if !user.IsActive {
+			continue
+		}
+
 		userCopy := user
-		ur, err := userResource(ctx, &userCopy, nil)
+		ur, err := userResource(ctx, &userCopy, organizationId)
 		if err != nil {
 			return nil, "", nil, err
 		}
@@ -180,14 +195,191 @@ func (o *roleResourceType) Grants(ctx context.Context, resource *v2.Resource, to
 			roleMember,
 			ur.Id,
 		))
+
+		// the permissions are only needed when the page has members of the role
+		if userRolePermissions == nil {
+			userRolePermissions, err = o.rolePermissions.get(ctx, organizationId, roleId)
+			if err != nil {
+				return nil, "", nil, wrapError(err, "failed to get user role permissions")
+			}
+		}
+
+		// members of the role hold every permission enabled on it
+		for pName, pValue := range userRolePermissions {
+			if !pValue {
+				continue
+			}
+
+			rv = append(rv, grant.NewGrant(
+				resource,
+				pName,
+				ur.Id,
+			))
+		}
+	}
+
+	nextToken, err := paginator.NextToken()
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	return rv, nextToken, rateLimitAnnotations(o.client), nil
+}
+
+// Grant assigns the role to the user. Bill.com users have exactly one role, so the role replaces the current
+// one. Granting the role the user already has changes nothing.
+func (o *roleResourceType) Grant(ctx context.Context, principal *v2.Resource, entitlement *v2.Entitlement) (annotations.Annotations, error) {
+	l := ctxzap.Extract(ctx)
+
+	organizationId, roleId, userId, err := parseRoleMembership(principal.Id, entitlement)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := o.client.GetUser(ctx, organizationId, userId)
+	if err != nil {
+		return nil, wrapError(err, "failed to get user")
+	}
+
+	logFields := []zap.Field{
+		zap.String("organization_id", organizationId),
+		zap.String("user_id", userId),
+		zap.String("role_id", roleId),
+	}
+
+	if user.RoleId == roleId {
+		l.Info("bill-connector: user already has the role", logFields...)
+		return rateLimitAnnotations(o.client), nil
+	}
+
+	if err := o.guard.checkChange(ctx, organizationId, user, roleId); err != nil {
+		return nil, err
+	}
+
+	if user.RoleId != "" {
+		l.Info(
+			"bill-connector: replacing the role of the user, Bill.com users have a single role",
+			append(logFields, zap.String("previous_role_id", user.RoleId))...,
+		)
+	}
+
+	user.RoleId = roleId
+
+	_, err = o.client.UpdateUser(ctx, organizationId, user)
+	if err != nil {
+		return nil, wrapError(err, "failed to assign role")
+	}
+
+	return rateLimitAnnotations(o.client), nil
+}
+
+// Revoke moves the user to the fallback role, since Bill.com users can't be left without a role.
+func (o *roleResourceType) Revoke(ctx context.Context, grant *v2.Grant) (annotations.Annotations, error) {
+	l := ctxzap.Extract(ctx)
+
+	organizationId, roleId, userId, err := parseRoleMembership(grant.Principal.Id, grant.Entitlement)
+	if err != nil {
+		return nil, err
+	}
+
+	if o.revokeFallbackRole == "" {
+		return nil, status.Errorf(codes.FailedPrecondition, "bill-connector: can't revoke role %s, users must have a role and no fallback role is configured", roleId)
+	}
+
+	user, err := o.client.GetUser(ctx, organizationId, userId)
+	if err != nil {
+		return nil, wrapError(err, "failed to get user")
+	}
+
+	logFields := []zap.Field{
+		zap.String("organization_id", organizationId),
+		zap.String("user_id", userId),
+		zap.String("role_id", roleId),
+	}
+
+	if user.RoleId != roleId {
+		l.Info("bill-connector: user doesn't have the role anymore", logFields...)
+		return rateLimitAnnotations(o.client), nil
+	}
+
+	fallbackRole, err := findRole(ctx, o.client, organizationId, o.revokeFallbackRole)
+	if err != nil {
+		return nil, err
+	}
+
+	if fallbackRole.Id == roleId {
+		return nil, status.Errorf(codes.FailedPrecondition, "bill-connector: can't revoke the fallback role %s", fallbackRole.Name)
+	}
+
+	if err := o.guard.checkChange(ctx, organizationId, user, fallbackRole.Id); err != nil {
+		return nil, err
+	}
+
+	l.Info("bill-connector: moving the user to the fallback role", append(logFields, zap.String("fallback_role_id", fallbackRole.Id))...)
+
+	user.RoleId = fallbackRole.Id
+
+	_, err = o.client.UpdateUser(ctx, organizationId, user)
+	if err != nil {
+		return nil, wrapError(err, "failed to revoke role")
+	}
+
+	return rateLimitAnnotations(o.client), nil
+}
+
+// parseRoleMembership returns the organization, role and user ids of a role membership. Only the membership
+// entitlement can be provisioned, permissions come with the role.
+func parseRoleMembership(principalId *v2.ResourceId, entitlement *v2.Entitlement) (string, string, string, error) {
+	if principalId.GetResourceType() != resourceTypeUser.Id {
+		return "", "", "", status.Errorf(codes.InvalidArgument, "bill-connector: roles can only be granted to users, not %s", principalId.GetResourceType())
+	}
+
+	if entitlement.GetId() != ent.NewEntitlementID(entitlement.GetResource(), roleMember) {
+		return "", "", "", status.Errorf(codes.Unimplemented, "bill-connector: entitlement %s can't be provisioned, only role membership can", entitlement.GetId())
+	}
+
+	organizationId, roleId, err := parseOrgScopedId(entitlement.GetResource().GetId())
+	if err != nil {
+		return "", "", "", err
+	}
+
+	userOrganizationId, userId, err := parseOrgScopedId(principalId)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	if userOrganizationId != organizationId {
+		return "", "", "", status.Errorf(codes.InvalidArgument, "bill-connector: user %s isn't in the organization %s of the role", userId, organizationId)
+	}
+
+	return organizationId, roleId, userId, nil
+}
+
+// findRole returns the role of the organization with the provided id or name, names are compared case-insensitively.
+func findRole(ctx context.Context, client *bill.Client, organizationId string, idOrName string) (*bill.UserRoleProfile, error) {
+	roles, err := listAll(ctx, func(ctx context.Context, params bill.PaginationParams) ([]bill.UserRoleProfile, error) {
+		return client.GetUserRoleProfiles(ctx, organizationId, params)
+	})
+	if err != nil {
+		return nil, wrapError(err, "failed to list user roles")
+	}
+
+	for _, role := range roles {
+		if role.Id == idOrName || strings.EqualFold(role.Name, idOrName) {
+			roleCopy := role
+			return &roleCopy, nil
+		}
 	}
 
-	return rv, strconv.Itoa(nextPage), nil, nil
+	return nil, status.Errorf(codes.FailedPrecondition, "bill-connector: role %s not found in organization %s", idOrName, organizationId)
 }
 
-func roleBuilder(client *bill.Client) *roleResourceType {
+func roleBuilder(client *bill.Client, rolePermissions *rolePermissions, revokeFallbackRole string, guard *adminGuard) *roleResourceType {
 	return &roleResourceType{
-		resourceType: resourceTypeRole,
-		client:       client,
+		resourceType:       resourceTypeRole,
+		client:             client,
+		rolePermissions:    rolePermissions,
+		revokeFallbackRole: revokeFallbackRole,
+		guard:              guard,
 	}
 }
@@ -0,0 +1,56 @@
+package connector
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ConductorOne/baton-bill/pkg/bill"
+)
+
+// rolePermissions caches the permissions of the roles for the duration of a sync,
+// since both the role and the permission syncers need them. The organization syncer resets it when a sync
+// starts listing organizations, so a connector serving many syncs sees permission changes.
+type rolePermissions struct {
+	client      *bill.Client
+	mtx         sync.Mutex
+	permissions map[string]map[string]bool
+}
+
+func newRolePermissions(client *bill.Client) *rolePermissions {
+	return &rolePermissions{
+		client:      client,
+		permissions: make(map[string]map[string]bool),
+	}
+}
+
+// reset drops the cached permissions.
+func (r *rolePermissions) reset() {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	r.permissions = make(map[string]map[string]bool)
+}
+
+// get returns the permissions of the role, keyed by permission name, with the enabled ones set to true.
+func (r *rolePermissions) get(ctx context.Context, organizationId string, roleId string) (map[string]bool, error) {
+	key := orgScopedId(organizationId, roleId)
+
+	r.mtx.Lock()
+	permissions, ok := r.permissions[key]
+	r.mtx.Unlock()
+
+	if ok {
+		return permissions, nil
+	}
+
+	permissions, err := r.client.GetUserRolePermissions(ctx, organizationId, roleId)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mtx.Lock()
+	r.permissions[key] = permissions
+	r.mtx.Unlock()
+
+	return permissions, nil
+}
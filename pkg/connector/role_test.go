@@ -0,0 +1,53 @@
+package connector_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRoleGrantsPermissionsToActiveMembers(t *testing.T) {
+	_, b := newTestConnector(t)
+
+	clerk := organizationChild(t, b, "role", "org-1:pro-2")
+
+	for _, slug := range []string{"member", "createBills"} {
+		if got := grantees(t, b, clerk, slug); !slices.Equal(got, []string{"org-1:usr-2"}) {
+			t.Errorf("got %s grantees %v, want only the active org-1:usr-2", slug, got)
+		}
+	}
+
+	// permissions disabled on the role aren't granted to anyone
+	if got := grantees(t, b, clerk, "payBills"); len(got) != 0 {
+		t.Errorf("got payBills grantees %v, want none", got)
+	}
+}
+
+func TestRoleAndUserIdsAreScopedToTheirOrganization(t *testing.T) {
+	_, b := newTestConnector(t)
+
+	// both organizations have an Administrator role and the user Ada
+	acmeAdmin := organizationChild(t, b, "role", "org-1:pro-1")
+	europeAdmin := organizationChild(t, b, "role", "org-2:pro-3")
+	europeAda := organizationChild(t, b, "user", "org-2:usr-4")
+
+	if got := europeAda.ParentResourceId.GetResource(); got != "org-2" {
+		t.Errorf("got parent %s, want org-2", got)
+	}
+
+	if got := grantees(t, b, acmeAdmin, "member"); !slices.Equal(got, []string{"org-1:usr-1"}) {
+		t.Errorf("got org-1 Administrator members %v, want org-1:usr-1", got)
+	}
+
+	if got := grantees(t, b, europeAdmin, "member"); !slices.Equal(got, []string{"org-2:usr-4"}) {
+		t.Errorf("got org-2 Administrator members %v, want org-2:usr-4", got)
+	}
+
+	_, err := provisioner(t, b, acmeAdmin).Grant(context.Background(), europeAda, entitlement(t, b, acmeAdmin, "member"))
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("got error %v granting a role to a user of another organization, want InvalidArgument", err)
+	}
+}
@@ -2,8 +2,6 @@ package connector
 
 import (
 	"context"
-	"fmt"
-	"strconv"
 
 	"github.com/ConductorOne/baton-bill/pkg/bill"
 	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
@@ -21,24 +19,52 @@ func (u *userResourceType) ResourceType(_ context.Context) *v2.ResourceType {
 	return u.resourceType
 }
 
-// Create a new connector resource for an Bill User.
-func userResource(ctx context.Context, user *bill.User, parentResourceID *v2.ResourceId) (*v2.Resource, error) {
+// Create a new connector resource for an Bill User of the organization.
+func userResource(ctx context.Context, user *bill.User, organizationId string) (*v2.Resource, error) {
 	profile := map[string]interface{}{
-		"login":   user.Name,
-		"user_id": user.Id,
+		"login":           user.Email,
+		"user_id":         user.Id,
+		"organization_id": organizationId,
+		"first_name":      user.FirstName,
+		"last_name":       user.LastName,
+		"email":           user.Email,
+		"role_id":         user.RoleId,
+	}
+
+	// optional fields are only added when Bill.com returns them
+	optionalFields := map[string]string{
+		"timezone_id":       user.TimezoneId,
+		"partner_user_guid": user.PartnerUserGuid,
+		"created_time":      user.CreatedTime,
+		"updated_time":      user.UpdatedTime,
+	}
+	for key, value := range optionalFields {
+		if value != "" {
+			profile[key] = value
+		}
+	}
+
+	userStatus := v2.UserTrait_Status_STATUS_DISABLED
+	if user.IsActive {
+		userStatus = v2.UserTrait_Status_STATUS_ENABLED
 	}
 
 	userTraitOptions := []rs.UserTraitOption{
 		rs.WithUserProfile(profile),
-		rs.WithStatus(v2.UserTrait_Status_STATUS_UNSPECIFIED),
+		rs.WithStatus(userStatus),
+		rs.WithAccountType(v2.UserTrait_ACCOUNT_TYPE_HUMAN),
+	}
+
+	if user.Email != "" {
+		userTraitOptions = append(userTraitOptions, rs.WithEmail(user.Email, true))
 	}
 
 	resource, err := rs.NewUserResource(
-		user.Name,
+		user.DisplayName(),
 		resourceTypeUser,
-		user.Id,
+		orgScopedId(organizationId, user.Id),
 		userTraitOptions,
-		rs.WithParentResourceID(parentResourceID),
+		rs.WithParentResourceID(organizationResourceId(organizationId)),
 	)
 
 	if err != nil {
@@ -53,23 +79,26 @@ func (u *userResourceType) List(ctx context.Context, parentId *v2.ResourceId, to
 		return nil, "", nil, nil
 	}
 
-	page, err := handlePageToken(token)
+	paginator, err := paginate(
+		token,
+		&v2.ResourceId{ResourceType: resourceTypeUser.Id},
+		func(ctx context.Context, params bill.PaginationParams) ([]bill.User, error) {
+			return u.client.GetUsers(ctx, parentId.Resource, bill.UserParams{PaginationParams: params, SearchParams: usersSearchParams})
+		},
+	)
 	if err != nil {
 		return nil, "", nil, err
 	}
 
-	users, nextPage, err := u.client.GetUsers(
-		ctx,
-		bill.PaginationParams{Max: ResourcesPageSize, Start: page},
-	)
+	users, err := paginator.Next(ctx)
 	if err != nil {
-		return nil, "", nil, fmt.Errorf("bill-connector: failed to list users: %w", err)
+		return nil, "", nil, wrapError(err, "failed to list users")
 	}
 
 	var rv []*v2.Resource
 	for _, user := range users {
 		userCopy := user
-		ir, err := userResource(ctx, &userCopy, parentId)
+		ir, err := userResource(ctx, &userCopy, parentId.Resource)
 
 		if err != nil {
 			return nil, "", nil, err
@@ -78,7 +107,12 @@ func (u *userResourceType) List(ctx context.Context, parentId *v2.ResourceId, to
 		rv = append(rv, ir)
 	}
 
-	return rv, strconv.Itoa(nextPage), nil, nil
+	nextToken, err := paginator.NextToken()
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	return rv, nextToken, rateLimitAnnotations(u.client), nil
 }
 
 func (u *userResourceType) Entitlements(ctx context.Context, resource *v2.Resource, token *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
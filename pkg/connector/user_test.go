@@ -0,0 +1,47 @@
+package connector_test
+
+import (
+	"testing"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+)
+
+func TestUserTraits(t *testing.T) {
+	_, b := newTestConnector(t)
+
+	tests := []struct {
+		id         string
+		status     v2.UserTrait_Status_Status
+		email      string
+		createdSet bool
+	}{
+		{"org-1:usr-1", v2.UserTrait_Status_STATUS_ENABLED, "ada@example.com", true},
+		{"org-1:usr-3", v2.UserTrait_Status_STATUS_DISABLED, "dora@example.com", false},
+	}
+
+	for _, tt := range tests {
+		resource := organizationChild(t, b, "user", tt.id)
+
+		trait, err := rs.GetUserTrait(resource)
+		if err != nil {
+			t.Fatalf("GetUserTrait of %s: %v", tt.id, err)
+		}
+
+		if got := trait.GetStatus().GetStatus(); got != tt.status {
+			t.Errorf("got %s status %s, want %s", tt.id, got, tt.status)
+		}
+
+		if emails := trait.GetEmails(); len(emails) != 1 || emails[0].Address != tt.email || !emails[0].IsPrimary {
+			t.Errorf("got %s emails %v, want the primary %s", tt.id, emails, tt.email)
+		}
+
+		if _, ok := rs.GetProfileStringValue(trait.GetProfile(), "created_time"); ok != tt.createdSet {
+			t.Errorf("got %s created_time set %v, want %v", tt.id, ok, tt.createdSet)
+		}
+
+		if organizationId, _ := rs.GetProfileStringValue(trait.GetProfile(), "organization_id"); organizationId != "org-1" {
+			t.Errorf("got %s organization_id %q, want org-1", tt.id, organizationId)
+		}
+	}
+}